@@ -3,14 +3,64 @@ package constants
 
 // Error messages
 const (
-	NilTpl               = "Template pointer is not initialised."
-	NilMongoClient       = "DB Client is not initialised."
-	DatabaseNotConnected = "Unable to connect database."
-	CollectionNotFound   = "Collection not found."
-	NilMongoHelper       = "Mongo helper is not initialised."
-	InvalidString        = "Enter a string value."
-	InvalidInteger       = "Enter an integer value."
-	InvalidName          = "Name must be at least 5 characters."
-	InvalidPassword      = "Password must be at least 8 characters."
-	InvalidEmail         = "Invalid email address."
+	NilTpl                = "Template pointer is not initialised."
+	NilMongoClient        = "DB Client is not initialised."
+	DatabaseNotConnected  = "Unable to connect database."
+	CollectionNotFound    = "Collection not found."
+	NilMongoHelper        = "Mongo helper is not initialised."
+	NilTransactionFunc    = "Transaction function is not provided."
+	NoMongoHosts          = "Connection string or hosts are not provided."
+	NoMongoDatabase       = "Database name is not provided."
+	InvalidCAFile         = "No certificates found in CA file."
+	InvalidFilter         = "Invalid operand for query operator"
+	InvalidUpdate         = "Invalid update document."
+	UnsupportedOperator   = "Unsupported operator"
+	InvalidMigration      = "Migration needs a unique positive version and an Up function:"
+	IrreversibleMigration = "Migration cannot be reverted:"
+	VersionConflict       = "Document was changed by another update:"
+	NilChangeHandler      = "Change handler is not provided."
+	NoFullDocument        = "Change event does not include the full document."
+	InvalidAggregation    = "Aggregation is not provided."
+	DocumentNotFound      = "Document not found."
+	DuplicateKey          = "Duplicate key."
+	DatabaseTimeout       = "Database operation timed out."
+	InvalidDocumentID     = "Invalid document id."
+	InvalidIndex          = "Index needs a collection and keys:"
+	LockHeld              = "Lock is held by another owner."
+	LockLost              = "Lock lease has expired or was released."
+	InvalidLockTTL        = "Lock lease duration must be at least a millisecond."
+	InvalidRateLimit      = "Rate limit needs a positive limit and window."
+	InvalidString         = "Enter a string value."
+	InvalidInteger        = "Enter an integer value."
+	InvalidName           = "Name must be at least 5 characters."
+	InvalidPassword       = "Password must be at least 8 characters."
+	FileTooLarge          = "File is too large."
+	InvalidFileType       = "File type is not accepted."
+	InvalidEmail          = "Invalid email address."
+	InvalidEmailDomain    = "Email domain is not a valid host name."
+	DisposableEmail       = "Disposable email addresses are not accepted."
+	NoMailServer          = "Email domain does not accept mail."
+	NoRecipients          = "Email has no recipients."
+	NoSender              = "Email sender (From) is required."
+	NilTransport          = "Mail transport is not initialised."
+	InvalidMailAddress    = "Invalid mail address:"
+	InvalidMailHeader     = "Invalid mail header:"
+	InvalidContentID      = "Invalid content id:"
+	QueueClosed           = "Mail queue is shut down."
+	MailClaimLost         = "Queued mail is claimed by another queue."
+	TLSUnavailable        = "Mail server does not support STARTTLS."
+	UnsupportedAuth       = "Unsupported mail authentication mechanism:"
+	UnencryptedAuth       = "Refusing to send credentials over an unencrypted connection."
+	WrongAuthHost         = "Mail server name does not match the authentication host."
+	UnexpectedChallenge   = "Unexpected mail authentication challenge:"
+	UnknownTemplate       = "Unknown email template:"
+	UnknownLayout         = "Unknown email layout:"
+	NilRegistry           = "Email template registry is not initialised."
+	NilTokens             = "Token issuer is not initialised."
+	ShortTokenSecret      = "Token secret must hold at least 32 bytes."
+	InvalidToken          = "Invalid token."
+	NoTokenPurpose        = "Token purpose is not provided."
+	TokenExpired          = "Token has expired."
+	TokenUsed             = "Token was already used."
+	InvalidLinkURL        = "Link needs an absolute URL:"
 )
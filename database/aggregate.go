@@ -0,0 +1,232 @@
+package database
+
+import (
+	"github.com/programmer-richa/utility/constants"
+	"context"
+	"errors"
+	"strings"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Aggregation builds an aggregation pipeline run by MongoHelper.Aggregate.
+// Stages are appended in the order the methods are called, e.g.
+//
+//	NewAggregation().
+//		Match(bson.M{"status": "paid"}).
+//		Group("$customer", bson.M{"total": Sum("$amount")}).
+//		Sort(bson.D{{Key: "total", Value: -1}}).
+//		Limit(10)
+type Aggregation struct {
+	pipeline     mongo.Pipeline
+	allowDiskUse bool
+	batchSize    int32
+}
+
+// NewAggregation returns an Aggregation without stages.
+func NewAggregation() *Aggregation {
+	return &Aggregation{pipeline: mongo.Pipeline{}}
+}
+
+// Stage appends a stage with the operator name, e.g. "$sample", and its specification.
+// It adds the stages without a dedicated method.
+func (a *Aggregation) Stage(name string, specification interface{}) *Aggregation {
+	a.pipeline = append(a.pipeline, bson.D{{Key: name, Value: specification}})
+	return a
+}
+
+// Match keeps the documents satisfying filter.
+func (a *Aggregation) Match(filter bson.M) *Aggregation {
+	return a.Stage("$match", filter)
+}
+
+// Group groups the documents by the expression id, e.g. "$customer" or nil for a single group,
+// and computes fields with accumulators such as Sum.
+func (a *Aggregation) Group(id interface{}, fields bson.M) *Aggregation {
+	group := bson.M{"_id": id}
+	for name, accumulator := range fields {
+		group[name] = accumulator
+	}
+	return a.Stage("$group", group)
+}
+
+// Lookup joins the documents of the collection from whose foreignField equals localField,
+// storing them as an array in the field as.
+func (a *Aggregation) Lookup(from string, localField string, foreignField string, as string) *Aggregation {
+	return a.Stage("$lookup", bson.M{"from": from, "localField": localField, "foreignField": foreignField, "as": as})
+}
+
+// LookupPipeline joins the documents of the collection from selected by pipeline,
+// storing them as an array in the field as. The variables in let are available to pipeline.
+func (a *Aggregation) LookupPipeline(from string, let bson.M, pipeline mongo.Pipeline, as string) *Aggregation {
+	lookup := bson.M{"from": from, "pipeline": pipeline, "as": as}
+	if len(let) > 0 {
+		lookup["let"] = let
+	}
+	return a.Stage("$lookup", lookup)
+}
+
+// Unwind outputs a document for every element of the array at path.
+// preserveEmpty keeps documents whose array is missing or empty.
+func (a *Aggregation) Unwind(path string, preserveEmpty bool) *Aggregation {
+	if !strings.HasPrefix(path, "$") {
+		path = "$" + path
+	}
+	if !preserveEmpty {
+		return a.Stage("$unwind", path)
+	}
+	return a.Stage("$unwind", bson.M{"path": path, "preserveNullAndEmptyArrays": true})
+}
+
+// Project includes, excludes or computes the fields of the documents.
+func (a *Aggregation) Project(fields bson.M) *Aggregation {
+	return a.Stage("$project", fields)
+}
+
+// AddFields adds computed fields to the documents.
+func (a *Aggregation) AddFields(fields bson.M) *Aggregation {
+	return a.Stage("$addFields", fields)
+}
+
+// Sort orders the documents by fields, 1 for ascending and -1 for descending.
+func (a *Aggregation) Sort(fields bson.D) *Aggregation {
+	return a.Stage("$sort", fields)
+}
+
+// Skip skips the first n documents.
+func (a *Aggregation) Skip(n int64) *Aggregation {
+	return a.Stage("$skip", n)
+}
+
+// Limit keeps the first n documents.
+func (a *Aggregation) Limit(n int64) *Aggregation {
+	return a.Stage("$limit", n)
+}
+
+// Count replaces the documents with a single document holding their number in field.
+func (a *Aggregation) Count(field string) *Aggregation {
+	return a.Stage("$count", field)
+}
+
+// AllowDiskUse lets stages exceeding the memory limit of the server write temporary files.
+func (a *Aggregation) AllowDiskUse(allow bool) *Aggregation {
+	a.allowDiskUse = allow
+	return a
+}
+
+// BatchSize sets the number of documents returned by the server in each batch.
+func (a *Aggregation) BatchSize(size int32) *Aggregation {
+	a.batchSize = size
+	return a
+}
+
+// Pipeline returns the stages of the aggregation.
+func (a *Aggregation) Pipeline() mongo.Pipeline {
+	return a.pipeline
+}
+
+// options returns the driver options of the aggregation.
+func (a *Aggregation) options() *options.AggregateOptions {
+	aggregateOptions := options.Aggregate()
+	if a.allowDiskUse {
+		aggregateOptions.SetAllowDiskUse(true)
+	}
+	if a.batchSize > 0 {
+		aggregateOptions.SetBatchSize(a.batchSize)
+	}
+	return aggregateOptions
+}
+
+// Sum adds up the numeric values of expression in a group, e.g. Sum("$amount"), or counts the documents with Sum(1).
+func Sum(expression interface{}) bson.M {
+	return bson.M{"$sum": expression}
+}
+
+// Avg averages the numeric values of expression in a group.
+func Avg(expression interface{}) bson.M {
+	return bson.M{"$avg": expression}
+}
+
+// Min returns the lowest value of expression in a group.
+func Min(expression interface{}) bson.M {
+	return bson.M{"$min": expression}
+}
+
+// Max returns the highest value of expression in a group.
+func Max(expression interface{}) bson.M {
+	return bson.M{"$max": expression}
+}
+
+// First returns the value of expression for the first document of a group.
+func First(expression interface{}) bson.M {
+	return bson.M{"$first": expression}
+}
+
+// Last returns the value of expression for the last document of a group.
+func Last(expression interface{}) bson.M {
+	return bson.M{"$last": expression}
+}
+
+// Push collects the values of expression in a group into an array.
+func Push(expression interface{}) bson.M {
+	return bson.M{"$push": expression}
+}
+
+// AddToSet collects the distinct values of expression in a group into an array.
+func AddToSet(expression interface{}) bson.M {
+	return bson.M{"$addToSet": expression}
+}
+
+// Aggregate runs aggregation on the collection and decodes the resulting documents
+// into results, a pointer to a slice of structs or maps.
+// It joins the transaction started by WithTransaction when given its context.
+func (m *MongoHelper) Aggregate(ctx context.Context, collectionName string, aggregation *Aggregation, results interface{}) (err error) {
+	defer classify(&err)
+	cursor, release, err := m.aggregate(ctx, collectionName, aggregation)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return cursor.All(ctx, results)
+}
+
+// AggregateOne runs aggregation on the collection and decodes the first resulting document into result.
+// It returns an error matching ErrNotFound if the aggregation yields no document.
+func (m *MongoHelper) AggregateOne(ctx context.Context, collectionName string, aggregation *Aggregation, result interface{}) (err error) {
+	defer classify(&err)
+	cursor, release, err := m.aggregate(ctx, collectionName, aggregation)
+	if err != nil {
+		return err
+	}
+	defer release()
+	defer cursor.Close(ctx)
+	if !cursor.Next(ctx) {
+		if err := cursor.Err(); err != nil {
+			return err
+		}
+		return mongo.ErrNoDocuments
+	}
+	return cursor.Decode(result)
+}
+
+// aggregate opens a cursor over the results of aggregation.
+// The returned release function must be called once the cursor is consumed.
+func (m *MongoHelper) aggregate(ctx context.Context, collectionName string, aggregation *Aggregation) (*mongo.Cursor, func(), error) {
+	if m == nil {
+		return nil, nil, errors.New(constants.NilMongoHelper)
+	}
+	if aggregation == nil {
+		return nil, nil, errors.New(constants.InvalidAggregation)
+	}
+	collection, release, err := m.collection(ctx, collectionName)
+	if err != nil {
+		return nil, nil, err
+	}
+	cursor, err := collection.Aggregate(ctx, aggregation.pipeline, aggregation.options())
+	if err != nil {
+		release()
+		return nil, nil, err
+	}
+	return cursor, release, nil
+}
@@ -0,0 +1,129 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// TestAggregationPipeline runs several test cases to check the correctness of
+// the aggregation builder defined in database package.
+func TestAggregationPipeline(t *testing.T) {
+	tests := []struct {
+		name        string
+		aggregation *Aggregation
+		pipeline    mongo.Pipeline
+	}{
+		{
+			"Empty",
+			NewAggregation(),
+			mongo.Pipeline{},
+		}, {
+			"Match and group",
+			NewAggregation().
+				Match(bson.M{"status": "paid"}).
+				Group("$customer", bson.M{"total": Sum("$amount"), "orders": Sum(1)}),
+			mongo.Pipeline{
+				{{Key: "$match", Value: bson.M{"status": "paid"}}},
+				{{Key: "$group", Value: bson.M{"_id": "$customer", "total": bson.M{"$sum": "$amount"}, "orders": bson.M{"$sum": 1}}}},
+			},
+		}, {
+			"Lookup and unwind",
+			NewAggregation().
+				Lookup("users", "user", "_id", "user").
+				Unwind("user", false).
+				Unwind("$items", true),
+			mongo.Pipeline{
+				{{Key: "$lookup", Value: bson.M{"from": "users", "localField": "user", "foreignField": "_id", "as": "user"}}},
+				{{Key: "$unwind", Value: "$user"}},
+				{{Key: "$unwind", Value: bson.M{"path": "$items", "preserveNullAndEmptyArrays": true}}},
+			},
+		}, {
+			"Project, sort and page",
+			NewAggregation().
+				Project(bson.M{"name": 1}).
+				Sort(bson.D{{Key: "name", Value: 1}}).
+				Skip(20).
+				Limit(10),
+			mongo.Pipeline{
+				{{Key: "$project", Value: bson.M{"name": 1}}},
+				{{Key: "$sort", Value: bson.D{{Key: "name", Value: 1}}}},
+				{{Key: "$skip", Value: int64(20)}},
+				{{Key: "$limit", Value: int64(10)}},
+			},
+		}, {
+			"Custom stage",
+			NewAggregation().Stage("$sample", bson.M{"size": 5}).Count("total"),
+			mongo.Pipeline{
+				{{Key: "$sample", Value: bson.M{"size": 5}}},
+				{{Key: "$count", Value: "total"}},
+			},
+		},
+	}
+
+	for _, c := range tests {
+		t.Run(c.name, func(t *testing.T) {
+			if pipeline := c.aggregation.Pipeline(); !reflect.DeepEqual(pipeline, c.pipeline) {
+				t.Fatal("Aggregation", c.name, pipeline)
+			} else {
+				fmt.Println("Aggregation-", c.name, "Pass")
+			}
+		})
+	}
+}
+
+// TestAggregationOptions checks the driver options set by the aggregation builder.
+func TestAggregationOptions(t *testing.T) {
+	aggregateOptions := NewAggregation().AllowDiskUse(true).BatchSize(100).options()
+	if aggregateOptions.AllowDiskUse == nil || !*aggregateOptions.AllowDiskUse ||
+		aggregateOptions.BatchSize == nil || *aggregateOptions.BatchSize != 100 {
+		t.Fatal("Aggregation options", aggregateOptions)
+	}
+	aggregateOptions = NewAggregation().options()
+	if aggregateOptions.AllowDiskUse != nil || aggregateOptions.BatchSize != nil {
+		t.Fatal("Aggregation default options", aggregateOptions)
+	}
+	fmt.Println("Aggregation options- Pass")
+}
+
+// TestMongoAggregate checks running an aggregation against a live database.
+// It is skipped if the database configured in constants is unreachable.
+func TestMongoAggregate(t *testing.T) {
+	testStores(t)
+	if liveHelper == nil {
+		t.Skip("MongoDB is unreachable")
+	}
+	defer liveHelper.RemoveCollection("aggregate_test")
+	for _, order := range []bson.M{
+		{"customer": "a", "amount": 10},
+		{"customer": "a", "amount": 5},
+		{"customer": "b", "amount": 7},
+	} {
+		if _, err := liveHelper.InsertDocument("aggregate_test", order); err != nil {
+			t.Fatal("InsertDocument", err)
+		}
+	}
+	aggregation := NewAggregation().
+		Group("$customer", bson.M{"total": Sum("$amount")}).
+		Sort(bson.D{{Key: "_id", Value: 1}})
+	var totals []struct {
+		Customer string `bson:"_id"`
+		Total    int    `bson:"total"`
+	}
+	ctx := context.Background()
+	if err := liveHelper.Aggregate(ctx, "aggregate_test", aggregation, &totals); err != nil ||
+		len(totals) != 2 || totals[0].Total != 15 || totals[1].Total != 7 {
+		t.Fatal("Aggregate", totals, err)
+	}
+	var top struct {
+		Total int `bson:"total"`
+	}
+	aggregation = NewAggregation().Group(nil, bson.M{"total": Max("$amount")})
+	if err := liveHelper.AggregateOne(ctx, "aggregate_test", aggregation, &top); err != nil || top.Total != 10 {
+		t.Fatal("AggregateOne", top, err)
+	}
+	fmt.Println("Aggregate- Pass")
+}
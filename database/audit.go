@@ -0,0 +1,70 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+// Keys of the update fields of AuditFields, stamped by Update.Stamp.
+const (
+	updatedAtField = "updatedAt"
+	updatedByField = "updatedBy"
+)
+
+// actorKey is the context key under which WithActor stores the acting user.
+type actorKey struct{}
+
+// WithActor returns a copy of ctx carrying actor, the user on whose behalf documents are written.
+// The audit fields of documents written under the returned context are stamped with actor.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor stored by WithActor, or an empty string if there is none.
+func ActorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return actor
+}
+
+// Auditable is implemented by models whose audit fields are stamped when they are written.
+// Models usually implement it by embedding AuditFields.
+type Auditable interface {
+	// StampCreated records the creation of the document by actor at the given time.
+	StampCreated(at time.Time, actor string)
+	// StampUpdated records a change of the document by actor at the given time.
+	StampUpdated(at time.Time, actor string)
+}
+
+// AuditFields holds the creation and last change of a document.
+// Embedding it inline in a model, passed by pointer, stamps the fields on InsertDocument and UpdateDocument:
+//
+//	type User struct {
+//		ID                   primitive.ObjectID `bson:"_id,omitempty"`
+//		Name                 string             `bson:"name"`
+//		database.AuditFields `bson:",inline"`
+//	}
+//
+// The fields are omitted when empty, so updates keep the stored creation fields.
+// Updates applied with ApplyUpdate stamp the update fields if built with Update.Stamp.
+type AuditFields struct {
+	CreatedAt time.Time `bson:"createdAt,omitempty"`
+	UpdatedAt time.Time `bson:"updatedAt,omitempty"`
+	CreatedBy string    `bson:"createdBy,omitempty"`
+	UpdatedBy string    `bson:"updatedBy,omitempty"`
+}
+
+// StampCreated sets the creation and update fields.
+func (a *AuditFields) StampCreated(at time.Time, actor string) {
+	a.CreatedAt, a.CreatedBy = at, actor
+	a.StampUpdated(at, actor)
+}
+
+// StampUpdated sets the update fields.
+func (a *AuditFields) StampUpdated(at time.Time, actor string) {
+	a.UpdatedAt, a.UpdatedBy = at, actor
+}
+
+// auditTime returns the current time as stored by MongoDB, in UTC with millisecond precision.
+func auditTime() time.Time {
+	return time.Now().UTC().Truncate(time.Millisecond)
+}
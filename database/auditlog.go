@@ -0,0 +1,181 @@
+package database
+
+import (
+	"github.com/programmer-richa/utility/constants"
+	"context"
+	"errors"
+	"sort"
+	"time"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AuditCollection is the default collection of the audit trail.
+const AuditCollection = "_audit"
+
+// FieldChange is the change of a single field, addressed by its dotted path.
+// Before is nil for added fields and After is nil for removed fields.
+type FieldChange struct {
+	Field  string      `bson:"field"`
+	Before interface{} `bson:"before,omitempty"`
+	After  interface{} `bson:"after,omitempty"`
+}
+
+// AuditEntry records a write of a document.
+type AuditEntry struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty"`
+	Collection string             `bson:"collection"`
+	DocumentID interface{}        `bson:"documentId"`
+	// Operation is OperationInsert, OperationUpdate or OperationDelete.
+	Operation string        `bson:"operation"`
+	Actor     string        `bson:"actor,omitempty"`
+	Timestamp time.Time     `bson:"timestamp"`
+	Changes   []FieldChange `bson:"changes"`
+}
+
+// EnableAuditTrail records every insert, update and delete made through the helper
+// as an AuditEntry in the collection auditCollection, or AuditCollection if it is empty.
+// The actor is taken from the context of the write, see WithActor.
+// It must be called before the helper is used.
+//
+// The entry is written after the document, so a failure to write it is returned
+// although the document was changed; inside WithTransaction both are rolled back.
+// RemoveCollection is not recorded.
+func (m *MongoHelper) EnableAuditTrail(auditCollection string) {
+	if auditCollection == "" {
+		auditCollection = AuditCollection
+	}
+	m.auditCollection = auditCollection
+}
+
+// auditing tests if the writes to the collection are recorded.
+func (m *MongoHelper) auditing(collectionName string) bool {
+	return m.auditCollection != "" && collectionName != m.auditCollection
+}
+
+// recordAudit writes the audit entry of a change of the document id from before to after.
+// Updates changing nothing are not recorded.
+func (m *MongoHelper) recordAudit(ctx context.Context, collectionName string, operation string,
+	id interface{}, before bson.M, after bson.M) error {
+	if !m.auditing(collectionName) {
+		return nil
+	}
+	changes := diffDocuments(before, after)
+	if operation == OperationUpdate && len(changes) == 0 {
+		return nil
+	}
+	collection, release, err := m.collection(ctx, m.auditCollection)
+	if err != nil {
+		return err
+	}
+	defer release()
+	_, err = collection.InsertOne(ctx, AuditEntry{
+		Collection: collectionName,
+		DocumentID: id,
+		Operation:  operation,
+		Actor:      ActorFromContext(ctx),
+		Timestamp:  auditTime(),
+		Changes:    changes,
+	})
+	return err
+}
+
+// DocumentHistory returns the audit entries of the document with the provided id, oldest first.
+// id is the hex form of an ObjectID, or the value of a string _id.
+func (m *MongoHelper) DocumentHistory(ctx context.Context, collectionName string, id string) (history []AuditEntry, err error) {
+	if m == nil {
+		return nil, errors.New(constants.NilMongoHelper)
+	}
+	defer classify(&err)
+	ids := bson.A{id}
+	if docID, err := primitive.ObjectIDFromHex(id); err == nil {
+		ids = append(ids, docID)
+	}
+	auditCollection := m.auditCollection
+	if auditCollection == "" {
+		auditCollection = AuditCollection
+	}
+	collection, release, err := m.collection(ctx, auditCollection)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	cursor, err := collection.Find(ctx,
+		bson.M{"collection": collectionName, "documentId": bson.M{"$in": ids}},
+		options.Find().SetSort(bson.D{{Key: "timestamp", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	history = []AuditEntry{}
+	err = cursor.All(ctx, &history)
+	return history, err
+}
+
+// diffDocuments returns the changes of the fields from before to after, ordered by path.
+// Embedded documents are compared field by field, arrays as a whole. The _id field is ignored.
+func diffDocuments(before bson.M, after bson.M) []FieldChange {
+	beforeFields, afterFields := map[string]interface{}{}, map[string]interface{}{}
+	flattenDocument(beforeFields, "", before)
+	flattenDocument(afterFields, "", after)
+	changes := []FieldChange{}
+	for path, value := range beforeFields {
+		if newValue, ok := afterFields[path]; !ok || !valuesEqual(value, newValue) {
+			changes = append(changes, FieldChange{Field: path, Before: value, After: newValue})
+		}
+	}
+	for path, value := range afterFields {
+		if _, ok := beforeFields[path]; !ok {
+			changes = append(changes, FieldChange{Field: path, After: value})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
+
+// flattenDocument adds the fields of doc to fields, keyed by their dotted paths under prefix.
+func flattenDocument(fields map[string]interface{}, prefix string, doc bson.M) {
+	for key, value := range doc {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		} else if key == "_id" {
+			continue
+		}
+		if embedded, ok := value.(bson.M); ok && len(embedded) > 0 {
+			flattenDocument(fields, path, embedded)
+		} else {
+			fields[path] = value
+		}
+	}
+}
+
+// auditDocument returns the document written by an operation, or nil if the
+// writes to the collection are not recorded.
+func (m *MongoHelper) auditDocument(collectionName string, result *mongo.SingleResult) (bson.M, error) {
+	if !m.auditing(collectionName) {
+		return nil, nil
+	}
+	doc := bson.M{}
+	err := result.Decode(&doc)
+	return doc, err
+}
+
+// auditUpdate records the update of the document id, whose previous version is returned in result.
+func (m *MongoHelper) auditUpdate(ctx context.Context, collectionName string, id interface{},
+	result *mongo.SingleResult, update *Update) error {
+	before, err := m.auditDocument(collectionName, result)
+	if before == nil || err != nil {
+		return err
+	}
+	document, err := toDocument(update.Document())
+	if err != nil {
+		return err
+	}
+	after, err := applyUpdate(before, document)
+	if err != nil {
+		return err
+	}
+	return m.recordAudit(ctx, collectionName, OperationUpdate, id, before, after)
+}
@@ -0,0 +1,112 @@
+package database
+
+import (
+	"github.com/programmer-richa/utility/constants"
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TestDiffDocuments runs several test cases to check the correctness of
+// the change computation of the audit trail defined in database package.
+func TestDiffDocuments(t *testing.T) {
+	id := primitive.NewObjectID()
+	tests := []struct {
+		name    string
+		before  bson.M
+		after   bson.M
+		changes []FieldChange
+	}{
+		{
+			"Insert",
+			nil,
+			bson.M{"_id": id, "name": "Richa", "age": int32(30)},
+			[]FieldChange{{Field: "age", After: int32(30)}, {Field: "name", After: "Richa"}},
+		}, {
+			"Delete",
+			bson.M{"_id": id, "name": "Richa"},
+			nil,
+			[]FieldChange{{Field: "name", Before: "Richa"}},
+		}, {
+			"Changed and removed fields",
+			bson.M{"_id": id, "name": "Richa", "age": int32(30), "city": "Delhi"},
+			bson.M{"_id": id, "name": "Richa", "age": int64(31)},
+			[]FieldChange{{Field: "age", Before: int32(30), After: int64(31)}, {Field: "city", Before: "Delhi"}},
+		}, {
+			"Embedded document",
+			bson.M{"_id": id, "address": bson.M{"city": "Delhi", "zip": "110001"}},
+			bson.M{"_id": id, "address": bson.M{"city": "Pune", "zip": "110001"}},
+			[]FieldChange{{Field: "address.city", Before: "Delhi", After: "Pune"}},
+		}, {
+			"Array",
+			bson.M{"_id": id, "tags": bson.A{"a"}},
+			bson.M{"_id": id, "tags": bson.A{"a", "b"}},
+			[]FieldChange{{Field: "tags", Before: bson.A{"a"}, After: bson.A{"a", "b"}}},
+		}, {
+			"Same number of another type",
+			bson.M{"_id": id, "age": int32(30)},
+			bson.M{"_id": id, "age": int64(30)},
+			[]FieldChange{},
+		},
+	}
+
+	for _, c := range tests {
+		t.Run(c.name, func(t *testing.T) {
+			if changes := diffDocuments(c.before, c.after); !reflect.DeepEqual(changes, c.changes) {
+				t.Fatal("diffDocuments", c.name, changes)
+			} else {
+				fmt.Println("diffDocuments-", c.name, "Pass")
+			}
+		})
+	}
+}
+
+// TestMongoAuditTrail checks the audit trail against a live database.
+// It is skipped if the database configured in constants is unreachable.
+func TestMongoAuditTrail(t *testing.T) {
+	testStores(t)
+	if liveHelper == nil {
+		t.Skip("MongoDB is unreachable")
+	}
+	helper := *liveHelper
+	helper.EnableAuditTrail("audit_test_log")
+	defer helper.RemoveCollection("audit_test_log")
+	defer helper.RemoveCollection("audit_test")
+	ctx := WithActor(context.Background(), "richa")
+	account := Account{Id: primitive.NewObjectID(), Owner: "Richa"}
+	if _, err := helper.InsertDocumentContext(ctx, "audit_test", account); err != nil {
+		t.Fatal("InsertDocument", err)
+	}
+	if err := helper.ApplyUpdate(ctx, "audit_test", account.Id.Hex(), NewUpdate().Inc("balance", 10)); err != nil {
+		t.Fatal("ApplyUpdate", err)
+	}
+	if err := helper.DeleteDocumentContext(ctx, "audit_test", account.Id.Hex(), nil); err != nil {
+		t.Fatal("DeleteDocument", err)
+	}
+	history, err := helper.DocumentHistory(ctx, "audit_test", account.Id.Hex())
+	if err != nil || len(history) != 3 {
+		t.Fatal("DocumentHistory", history, err)
+	}
+	for i, operation := range []string{OperationInsert, OperationUpdate, OperationDelete} {
+		if history[i].Operation != operation || history[i].Actor != "richa" {
+			t.Fatal("DocumentHistory", i, history[i])
+		}
+	}
+	if len(history[1].Changes) != 1 || history[1].Changes[0].Field != "balance" {
+		t.Fatal("DocumentHistory update changes", history[1].Changes)
+	}
+	fmt.Println("Audit trail- Pass")
+}
+
+// TestDocumentHistoryNilHelper checks that DocumentHistory rejects a nil helper without connecting.
+func TestDocumentHistoryNilHelper(t *testing.T) {
+	var helper *MongoHelper
+	if _, err := helper.DocumentHistory(context.Background(), "audit_test", "id"); err == nil ||
+		err.Error() != constants.NilMongoHelper {
+		t.Fatal("DocumentHistory", err)
+	}
+	fmt.Println("DocumentHistory- Nil helper Pass")
+}
@@ -0,0 +1,339 @@
+package database
+
+import (
+	"github.com/programmer-richa/utility/constants"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"net/url"
+	"strings"
+	"time"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+// redactedPassword replaces passwords in connection strings and error messages.
+const redactedPassword = "xxxxx"
+
+// TLSConfig holds the certificates used to secure the connection to the server.
+type TLSConfig struct {
+	// CAFile is the PEM file holding the certificate authorities trusted to sign the server certificate.
+	CAFile string
+	// CertFile and KeyFile hold the client certificate and its private key.
+	// KeyFile may be left empty when CertFile contains both.
+	CertFile string
+	KeyFile  string
+	// ServerName overrides the host name used to verify the server certificate.
+	ServerName string
+	// InsecureSkipVerify disables verification of the server certificate. Use for testing only.
+	InsecureSkipVerify bool
+}
+
+// WriteConcernConfig specifies the acknowledgement requested from the server for write operations.
+type WriteConcernConfig struct {
+	// W is the number of members that must acknowledge the write.
+	// It is ignored if Majority is set.
+	W        int
+	Majority bool
+	// Journal requests acknowledgement that the write reached the on-disk journal.
+	Journal bool
+	Timeout time.Duration
+}
+
+// Config holds the connection settings of a MongoHelper.
+// URI may hold a complete connection string, e.g. a mongodb+srv:// address,
+// while the remaining fields add to or override the settings it contains.
+type Config struct {
+	URI string
+	// Hosts lists host:port addresses of the deployment. It is ignored if URI is set.
+	Hosts []string
+	// SRV resolves the first host through a DNS seed list (mongodb+srv).
+	SRV bool
+	// Credentials are passed to the driver as is, so they need no escaping.
+	Username      string
+	Password      string
+	AuthSource    string
+	AuthMechanism string
+	ReplicaSet    string
+	// Database is the database used by the helper methods.
+	// It defaults to the database in the URI path.
+	Database string
+	TLS      *TLSConfig
+	// ReadPreference is one of primary, primaryPreferred, secondary, secondaryPreferred or nearest.
+	ReadPreference string
+	WriteConcern   *WriteConcernConfig
+	ConnectTimeout time.Duration
+}
+
+// NewMongoHelperFromURI returns a MongoHelper connecting with the provided connection string.
+// database may be empty if the connection string names the database in its path.
+func NewMongoHelperFromURI(uri string, database string) (*MongoHelper, error) {
+	return NewMongoHelperWithConfig(Config{URI: uri, Database: database})
+}
+
+// NewMongoHelperWithConfig returns a MongoHelper connecting with the provided configuration.
+// It returns an error if the configuration is invalid.
+func NewMongoHelperWithConfig(config Config) (*MongoHelper, error) {
+	if config.URI == "" && len(config.Hosts) == 0 {
+		return nil, errors.New(constants.NoMongoHosts)
+	}
+	// Validate the options up front rather than on the first connection.
+	if _, err := config.clientOptions(); err != nil {
+		return nil, err
+	}
+	if config.Database == "" {
+		config.Database = uriDatabase(config.URI)
+	}
+	if config.Database == "" {
+		return nil, errors.New(constants.NoMongoDatabase)
+	}
+	return &MongoHelper{config: config}, nil
+}
+
+// String returns the connection string of the configuration with the password redacted.
+// It is safe to use in logs.
+func (c Config) String() string {
+	if c.URI != "" {
+		return redactURI(c.URI)
+	}
+	scheme := "mongodb://"
+	if c.SRV {
+		scheme = "mongodb+srv://"
+	}
+	user := ""
+	if c.Username != "" {
+		user = url.User(c.Username).String() + "@"
+		if c.Password != "" {
+			user = url.UserPassword(c.Username, redactedPassword).String() + "@"
+		}
+	}
+	return scheme + user + strings.Join(c.Hosts, ",") + "/" + c.Database
+}
+
+// clientOptions converts the configuration to driver client options.
+func (c Config) clientOptions() (*options.ClientOptions, error) {
+	clientOptions := options.Client()
+	switch {
+	case c.URI != "":
+		clientOptions.ApplyURI(c.URI)
+	case c.SRV:
+		clientOptions.ApplyURI("mongodb+srv://" + c.Hosts[0])
+	default:
+		clientOptions.SetHosts(c.Hosts)
+	}
+	if err := clientOptions.Validate(); err != nil {
+		return nil, c.redactError(err)
+	}
+	if c.Username != "" {
+		auth := options.Credential{}
+		if clientOptions.Auth != nil {
+			auth = *clientOptions.Auth
+		}
+		auth.Username = c.Username
+		auth.Password = c.Password
+		auth.PasswordSet = c.Password != ""
+		clientOptions.SetAuth(auth)
+	}
+	if clientOptions.Auth != nil {
+		if c.AuthSource != "" {
+			clientOptions.Auth.AuthSource = c.AuthSource
+		}
+		if c.AuthMechanism != "" {
+			clientOptions.Auth.AuthMechanism = c.AuthMechanism
+		}
+	}
+	if c.ReplicaSet != "" {
+		clientOptions.SetReplicaSet(c.ReplicaSet)
+	}
+	if c.TLS != nil {
+		tlsConfig, err := c.TLS.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+		clientOptions.SetTLSConfig(tlsConfig)
+	}
+	if c.ReadPreference != "" {
+		mode, err := readpref.ModeFromString(c.ReadPreference)
+		if err != nil {
+			return nil, err
+		}
+		rp, err := readpref.New(mode)
+		if err != nil {
+			return nil, err
+		}
+		clientOptions.SetReadPreference(rp)
+	}
+	if c.WriteConcern != nil {
+		clientOptions.SetWriteConcern(c.WriteConcern.writeConcern())
+	}
+	if c.ConnectTimeout > 0 {
+		clientOptions.SetConnectTimeout(c.ConnectTimeout)
+	}
+	return clientOptions, nil
+}
+
+// redactError replaces the password of the configuration in the message of err, in its raw
+// and URL-escaped forms. The returned error unwraps to err, so its cause is still classified.
+func (c Config) redactError(err error) error {
+	if err == nil {
+		return nil
+	}
+	msg := err.Error()
+	redacted := msg
+	for _, password := range []string{c.Password, uriPassword(c.URI)} {
+		if password == "" {
+			continue
+		}
+		for _, form := range []string{password, url.QueryEscape(password), url.PathEscape(password)} {
+			redacted = replaceWord(redacted, form, redactedPassword)
+		}
+	}
+	if redacted == msg {
+		return err
+	}
+	return &redactedError{msg: redacted, err: err}
+}
+
+// redactedError is an error whose message has a password redacted.
+type redactedError struct {
+	msg string
+	err error
+}
+
+// Error returns the redacted message.
+func (e *redactedError) Error() string {
+	return e.msg
+}
+
+// Unwrap returns the original error.
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// replaceWord replaces the occurrences of word in s that are not part of a longer word,
+// so that a short password does not alter unrelated text.
+func replaceWord(s string, word string, replacement string) string {
+	var b strings.Builder
+	for {
+		i := strings.Index(s, word)
+		if i < 0 {
+			b.WriteString(s)
+			return b.String()
+		}
+		end := i + len(word)
+		if (i > 0 && isWordByte(s[i-1])) || (end < len(s) && isWordByte(s[end])) {
+			b.WriteString(s[:i+1])
+			s = s[i+1:]
+			continue
+		}
+		b.WriteString(s[:i] + replacement)
+		s = s[end:]
+	}
+}
+
+// isWordByte tests if c is an ASCII letter or digit.
+func isWordByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// splitURI splits a connection string into its scheme, user information, hosts and path.
+// Reserved characters in user information must be percent-encoded in a valid connection
+// string, so the first slash ends the host list and the last @ before it ends the user information.
+func splitURI(uri string) (scheme string, userInfo string, hosts string, path string) {
+	schemeEnd := strings.Index(uri, "://")
+	if schemeEnd < 0 {
+		return "", "", uri, ""
+	}
+	scheme, hosts = uri[:schemeEnd+3], uri[schemeEnd+3:]
+	if slash := strings.Index(hosts, "/"); slash >= 0 {
+		hosts, path = hosts[:slash], hosts[slash:]
+	}
+	if at := strings.LastIndex(hosts, "@"); at >= 0 {
+		userInfo, hosts = hosts[:at], hosts[at+1:]
+	}
+	return scheme, userInfo, hosts, path
+}
+
+// uriPassword returns the password held in the user information of uri.
+func uriPassword(uri string) string {
+	_, userInfo, _, _ := splitURI(uri)
+	colon := strings.Index(userInfo, ":")
+	if colon < 0 {
+		return ""
+	}
+	if password, err := url.PathUnescape(userInfo[colon+1:]); err == nil {
+		return password
+	}
+	return userInfo[colon+1:]
+}
+
+// redactURI returns uri with the password in its user information replaced.
+func redactURI(uri string) string {
+	scheme, userInfo, hosts, path := splitURI(uri)
+	colon := strings.Index(userInfo, ":")
+	if colon < 0 {
+		return uri
+	}
+	return scheme + userInfo[:colon+1] + redactedPassword + "@" + hosts + path
+}
+
+// uriDatabase returns the database named in the path of uri.
+func uriDatabase(uri string) string {
+	_, _, _, path := splitURI(uri)
+	path = strings.TrimPrefix(path, "/")
+	if query := strings.Index(path, "?"); query >= 0 {
+		path = path[:query]
+	}
+	database, err := url.PathUnescape(path)
+	if err != nil {
+		return ""
+	}
+	return database
+}
+
+// tlsConfig loads the certificates referenced by the TLS configuration.
+func (t *TLSConfig) tlsConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		ServerName:         t.ServerName,
+		InsecureSkipVerify: t.InsecureSkipVerify,
+	}
+	if t.CAFile != "" {
+		pem, err := ioutil.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New(constants.InvalidCAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if t.CertFile != "" {
+		keyFile := t.KeyFile
+		if keyFile == "" {
+			keyFile = t.CertFile
+		}
+		cert, err := tls.LoadX509KeyPair(t.CertFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+// writeConcern converts the configuration to a driver write concern.
+func (w *WriteConcernConfig) writeConcern() *writeconcern.WriteConcern {
+	opts := []writeconcern.Option{writeconcern.J(w.Journal)}
+	if w.Majority {
+		opts = append(opts, writeconcern.WMajority())
+	} else if w.W > 0 {
+		opts = append(opts, writeconcern.W(w.W))
+	}
+	if w.Timeout > 0 {
+		opts = append(opts, writeconcern.WTimeout(w.Timeout))
+	}
+	return writeconcern.New(opts...)
+}
@@ -0,0 +1,125 @@
+package database
+
+import (
+	"github.com/programmer-richa/utility/constants"
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/topology"
+)
+
+// Server error codes classified as timeouts.
+const (
+	maxTimeMSExpiredCode    = 50
+	writeConcernTimeoutCode = 64
+)
+
+// Errors matched by errors.Is against the errors returned by the document operations.
+var (
+	// ErrNotFound reports that no document matched, e.g. the id passed to FindDocument.
+	ErrNotFound = errors.New(constants.DocumentNotFound)
+	// ErrDuplicateKey reports a violation of the _id or another unique index.
+	ErrDuplicateKey = errors.New(constants.DuplicateKey)
+	// ErrTimeout reports an operation that did not complete in time, including
+	// failures to reach the server and expired contexts.
+	ErrTimeout = errors.New(constants.DatabaseTimeout)
+	// ErrInvalidID reports an id that is not the hex form of an ObjectID.
+	ErrInvalidID = errors.New(constants.InvalidDocumentID)
+)
+
+// Error is a failure of a document operation classified by Kind, one of ErrNotFound,
+// ErrDuplicateKey, ErrTimeout and ErrInvalidID. errors.Is matches both Kind and the
+// wrapped cause, e.g. mongo.ErrNoDocuments, and errors.As reaches the driver error.
+type Error struct {
+	Kind error
+	Err  error
+}
+
+// Error returns the error message.
+func (e *Error) Error() string {
+	return e.Kind.Error() + " " + e.Err.Error()
+}
+
+// Unwrap returns the cause of the error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is makes errors.Is(err, e.Kind) report true.
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+// classifyError wraps err in an *Error if it is a not found, duplicate key or timeout failure.
+// Other errors are returned unchanged.
+func classifyError(err error) error {
+	var classified *Error
+	if err == nil || errors.As(err, &classified) {
+		return err
+	}
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments), errors.Is(err, gridfs.ErrFileNotFound):
+		return &Error{Kind: ErrNotFound, Err: err}
+	case isDuplicateKeyError(err):
+		return &Error{Kind: ErrDuplicateKey, Err: err}
+	case isTimeoutError(err):
+		return &Error{Kind: ErrTimeout, Err: err}
+	}
+	return err
+}
+
+// classify replaces the error pointed to by err with its classification.
+// Operations returning a named error defer it.
+func classify(err *error) {
+	*err = classifyError(*err)
+}
+
+// objectID converts the hex form of an ObjectID, reporting failures as ErrInvalidID.
+func objectID(id string) (primitive.ObjectID, error) {
+	docID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return docID, &Error{Kind: ErrInvalidID, Err: err}
+	}
+	return docID, nil
+}
+
+// isDuplicateKeyError tests if err reports a unique index violation.
+func isDuplicateKeyError(err error) bool {
+	var writeErr mongo.WriteException
+	if errors.As(err, &writeErr) {
+		for _, we := range writeErr.WriteErrors {
+			if we.Code == duplicateKeyCode {
+				return true
+			}
+		}
+	}
+	var cmdErr mongo.CommandError
+	return errors.As(err, &cmdErr) && cmdErr.Code == duplicateKeyCode
+}
+
+// isTimeoutError tests if err reports an expired context, a network timeout,
+// a server selection timeout or a server side time limit.
+func isTimeoutError(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && cmdErr.Code == maxTimeMSExpiredCode {
+		return true
+	}
+	var writeErr mongo.WriteException
+	if errors.As(err, &writeErr) && writeErr.WriteConcernError != nil &&
+		writeErr.WriteConcernError.Code == writeConcernTimeoutCode {
+		return true
+	}
+	// The driver formats server selection failures without wrapping the cause.
+	return strings.Contains(err.Error(), topology.ErrServerSelectionTimeout.Error())
+}
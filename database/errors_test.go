@@ -0,0 +1,131 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// TestClassifyError runs several test cases to check the correctness of
+// the error classification defined in database package.
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name  string
+		err   error
+		kind  error
+		cause error
+	}{
+		{
+			"No document",
+			mongo.ErrNoDocuments,
+			ErrNotFound,
+			mongo.ErrNoDocuments,
+		}, {
+			"Duplicate key",
+			duplicateKeyError("users", "1"),
+			ErrDuplicateKey,
+			nil,
+		}, {
+			"Expired context",
+			fmt.Errorf("find: %w", context.DeadlineExceeded),
+			ErrTimeout,
+			context.DeadlineExceeded,
+		}, {
+			"Server time limit",
+			mongo.CommandError{Code: maxTimeMSExpiredCode, Message: "operation exceeded time limit"},
+			ErrTimeout,
+			nil,
+		}, {
+			"Server selection timeout",
+			errors.New("server selection error: server selection timeout, current topology: { Type: Unknown }"),
+			ErrTimeout,
+			nil,
+		}, {
+			"Other error",
+			mongo.CommandError{Code: 13, Message: "unauthorized"},
+			nil,
+			nil,
+		},
+	}
+
+	for _, c := range tests {
+		t.Run(c.name, func(t *testing.T) {
+			err := classifyError(c.err)
+			var classified *Error
+			var valid bool
+			if c.kind == nil {
+				valid = !errors.As(err, &classified) && err.Error() == c.err.Error()
+			} else {
+				valid = errors.Is(err, c.kind) && errors.As(err, &classified) && classified.Err.Error() == c.err.Error()
+			}
+			if c.cause != nil {
+				valid = valid && errors.Is(err, c.cause)
+			}
+			if !valid {
+				t.Fatal("classifyError", c.name, err)
+			} else {
+				fmt.Println("classifyError-", c.name, "Pass")
+			}
+		})
+	}
+}
+
+// TestStoreErrors runs several test cases to check that every DocumentStore
+// implementation reports failures with the exported error kinds.
+func TestStoreErrors(t *testing.T) {
+	id := primitive.NewObjectID()
+	tests := []struct {
+		name string
+		run  func(store DocumentStore) error
+		kind error
+	}{
+		{
+			"Duplicate key",
+			func(store DocumentStore) error {
+				_, err := store.InsertDocument("store_errors", Account{Id: id})
+				return err
+			},
+			ErrDuplicateKey,
+		}, {
+			"Missing document",
+			func(store DocumentStore) error {
+				_, err := store.FindDocument("store_errors", primitive.NewObjectID().Hex())
+				return err
+			},
+			ErrNotFound,
+		}, {
+			"Missing record",
+			func(store DocumentStore) error {
+				_, err := store.GetaRecord("store_errors", map[string]interface{}{"owner": "nobody"})
+				return err
+			},
+			ErrNotFound,
+		}, {
+			"Invalid id",
+			func(store DocumentStore) error {
+				_, err := store.FindDocument("store_errors", "not-an-id")
+				return err
+			},
+			ErrInvalidID,
+		},
+	}
+
+	for storeName, store := range testStores(t) {
+		t.Run(storeName, func(t *testing.T) {
+			defer store.RemoveCollection("store_errors")
+			if _, err := store.InsertDocument("store_errors", Account{Id: id}); err != nil {
+				t.Fatal("InsertDocument", storeName, err)
+			}
+			for _, c := range tests {
+				if err := c.run(store); !errors.Is(err, c.kind) {
+					t.Fatal("Store errors", storeName, c.name, err)
+				} else {
+					fmt.Println("Store errors-", storeName, c.name, "Pass")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package database
+
+import (
+	"github.com/programmer-richa/utility/constants"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"time"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ContentTypeMetadata is the metadata field in which UploadFileHeader records the content type of a file.
+const ContentTypeMetadata = "contentType"
+
+// sniffLength is the number of bytes http.DetectContentType considers.
+const sniffLength = 512
+
+// FileInfo describes a file stored in GridFS.
+type FileInfo struct {
+	ID         primitive.ObjectID `bson:"_id"`
+	Filename   string             `bson:"filename"`
+	Length     int64              `bson:"length"`
+	ChunkSize  int32              `bson:"chunkSize"`
+	UploadDate time.Time          `bson:"uploadDate"`
+	Metadata   bson.M             `bson:"metadata,omitempty"`
+}
+
+// FileStore stores files, such as avatars and invoices, in a GridFS bucket of the helper's database.
+// Files are split in chunks, so they are not limited by the maximum document size.
+// File operations do not join transactions started by WithTransaction.
+type FileStore struct {
+	helper *MongoHelper
+	bucket string
+	// ChunkSize is the size in bytes of the chunks of uploaded files. The driver default of 255 kB is used if it is 0.
+	ChunkSize int32
+}
+
+// NewFileStore returns a FileStore for the bucket named bucketName, or the default "fs" bucket if it is empty.
+// Files with the same name are kept as revisions, the latest one being returned by name lookups.
+func NewFileStore(helper *MongoHelper, bucketName string) (*FileStore, error) {
+	if helper == nil {
+		return nil, errors.New(constants.NilMongoHelper)
+	}
+	if bucketName == "" {
+		bucketName = options.DefaultName
+	}
+	return &FileStore{helper: helper, bucket: bucketName}, nil
+}
+
+// Upload stores the content of source as a file named filename with the provided metadata, which may be nil.
+// It returns the id of the file.
+func (s *FileStore) Upload(ctx context.Context, filename string, source io.Reader, metadata interface{}) (id primitive.ObjectID, err error) {
+	defer classify(&err)
+	bucket, release, err := s.open(ctx)
+	if err != nil {
+		return id, err
+	}
+	defer release()
+	uploadOptions := options.GridFSUpload()
+	if metadata != nil {
+		uploadOptions.SetMetadata(metadata)
+	}
+	if s.ChunkSize > 0 {
+		uploadOptions.SetChunkSizeBytes(s.ChunkSize)
+	}
+	return bucket.UploadFromStream(filename, source, uploadOptions)
+}
+
+// UploadFileHeader stores a file received in a multipart form, e.g. by a forms.FILE field,
+// under its original name. Unless metadata holds ContentTypeMetadata, the content type
+// sniffed from the content of the file is added to it. The content type declared by the client
+// is ignored, as serving it back would let clients upload HTML and scripts posing as images.
+func (s *FileStore) UploadFileHeader(ctx context.Context, header *multipart.FileHeader, metadata bson.M) (primitive.ObjectID, error) {
+	file, err := header.Open()
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	defer file.Close()
+	buffer := make([]byte, sniffLength)
+	n, err := io.ReadFull(file, buffer)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return primitive.NilObjectID, err
+	}
+	withType := bson.M{}
+	for key, value := range metadata {
+		withType[key] = value
+	}
+	if withType[ContentTypeMetadata] == nil {
+		withType[ContentTypeMetadata] = http.DetectContentType(buffer[:n])
+	}
+	return s.Upload(ctx, header.Filename, io.MultiReader(bytes.NewReader(buffer[:n]), file), withType)
+}
+
+// Download writes the content of the file with the provided id to w and returns the number of bytes written.
+// It returns an error matching ErrNotFound if there is no such file.
+func (s *FileStore) Download(ctx context.Context, id string, w io.Writer) (written int64, err error) {
+	defer classify(&err)
+	fileID, err := objectID(id)
+	if err != nil {
+		return 0, err
+	}
+	bucket, release, err := s.open(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+	return bucket.DownloadToStream(fileID, w)
+}
+
+// DownloadByName writes the content of the latest revision of the file named filename to w
+// and returns the number of bytes written.
+// It returns an error matching ErrNotFound if there is no such file.
+func (s *FileStore) DownloadByName(ctx context.Context, filename string, w io.Writer) (written int64, err error) {
+	defer classify(&err)
+	bucket, release, err := s.open(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+	return bucket.DownloadToStreamByName(filename, w)
+}
+
+// Find returns the description of the file with the provided id.
+// It returns an error matching ErrNotFound if there is no such file.
+func (s *FileStore) Find(ctx context.Context, id string) (FileInfo, error) {
+	fileID, err := objectID(id)
+	if err != nil {
+		return FileInfo{}, err
+	}
+	return s.findOne(ctx, bson.M{"_id": fileID})
+}
+
+// FindByName returns the description of the latest revision of the file named filename.
+// It returns an error matching ErrNotFound if there is no such file.
+func (s *FileStore) FindByName(ctx context.Context, filename string) (FileInfo, error) {
+	return s.findOne(ctx, bson.M{"filename": filename})
+}
+
+// List returns the descriptions of the files matching filter, newest first.
+// Filters address the fields of FileInfo by their bson names, e.g. "metadata.owner".
+func (s *FileStore) List(ctx context.Context, filter bson.M) ([]FileInfo, error) {
+	return s.find(ctx, filter, options.GridFSFind().SetSort(bson.D{{Key: "uploadDate", Value: -1}}))
+}
+
+// Delete removes the file with the provided id and its chunks.
+// It returns an error matching ErrNotFound if there is no such file.
+func (s *FileStore) Delete(ctx context.Context, id string) (err error) {
+	defer classify(&err)
+	fileID, err := objectID(id)
+	if err != nil {
+		return err
+	}
+	bucket, release, err := s.open(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return bucket.Delete(fileID)
+}
+
+// findOne returns the newest file matching filter.
+func (s *FileStore) findOne(ctx context.Context, filter bson.M) (FileInfo, error) {
+	files, err := s.find(ctx, filter,
+		options.GridFSFind().SetSort(bson.D{{Key: "uploadDate", Value: -1}}).SetLimit(1))
+	if err != nil {
+		return FileInfo{}, err
+	}
+	if len(files) == 0 {
+		return FileInfo{}, classifyError(gridfs.ErrFileNotFound)
+	}
+	return files[0], nil
+}
+
+// find returns the files matching filter.
+func (s *FileStore) find(ctx context.Context, filter bson.M, findOptions *options.GridFSFindOptions) (files []FileInfo, err error) {
+	defer classify(&err)
+	if filter == nil {
+		filter = bson.M{}
+	}
+	bucket, release, err := s.open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	cursor, err := bucket.Find(filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	files = []FileInfo{}
+	err = cursor.All(ctx, &files)
+	return files, err
+}
+
+// open returns the bucket on a new db session, bounded by the deadline of ctx.
+// The returned release function must be called once the operation is complete.
+func (s *FileStore) open(ctx context.Context) (*gridfs.Bucket, func(), error) {
+	if s == nil {
+		return nil, nil, errors.New(constants.NilMongoHelper)
+	}
+	client, err := s.helper.GetSession()
+	if err != nil {
+		return nil, nil, err
+	}
+	release := func() { client.Disconnect(context.TODO()) }
+	db, err := s.helper.GetDatabase(client)
+	if err != nil {
+		release()
+		return nil, nil, err
+	}
+	bucket, err := gridfs.NewBucket(db, options.GridFSBucket().SetName(s.bucket))
+	if err != nil {
+		release()
+		return nil, nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		bucket.SetReadDeadline(deadline)
+		bucket.SetWriteDeadline(deadline)
+	}
+	return bucket, release, nil
+}
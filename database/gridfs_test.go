@@ -0,0 +1,109 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/textproto"
+	"strings"
+	"testing"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TestNewFileStore checks that a FileStore needs a helper.
+func TestNewFileStore(t *testing.T) {
+	if _, err := NewFileStore(nil, ""); err == nil {
+		t.Fatal("NewFileStore accepted a nil helper")
+	}
+	store, err := NewFileStore(NewMongoHelper("root", "local", "localhost", "27017", "test"), "")
+	if err != nil || store.bucket != "fs" {
+		t.Fatal("NewFileStore", err)
+	}
+	fmt.Println("NewFileStore- Pass")
+}
+
+// TestFileStore checks storing and reading files against a live database.
+// It is skipped if the database configured in constants is unreachable.
+func TestFileStore(t *testing.T) {
+	testStores(t)
+	if liveHelper == nil {
+		t.Skip("MongoDB is unreachable")
+	}
+	ctx := context.Background()
+	store, err := NewFileStore(liveHelper, "filestore_test")
+	if err != nil {
+		t.Fatal("NewFileStore", err)
+	}
+	defer liveHelper.RemoveCollection("filestore_test.files")
+	defer liveHelper.RemoveCollection("filestore_test.chunks")
+	store.ChunkSize = 4
+	id, err := store.Upload(ctx, "invoice.txt", strings.NewReader("first revision"), bson.M{"owner": "richa"})
+	if err != nil {
+		t.Fatal("Upload", err)
+	}
+	if _, err := store.Upload(ctx, "invoice.txt", strings.NewReader("second revision"), nil); err != nil {
+		t.Fatal("Upload", err)
+	}
+	var content bytes.Buffer
+	if _, err := store.Download(ctx, id.Hex(), &content); err != nil || content.String() != "first revision" {
+		t.Fatal("Download", content.String(), err)
+	}
+	content.Reset()
+	if _, err := store.DownloadByName(ctx, "invoice.txt", &content); err != nil || content.String() != "second revision" {
+		t.Fatal("DownloadByName", content.String(), err)
+	}
+	info, err := store.Find(ctx, id.Hex())
+	if err != nil || info.Length != int64(len("first revision")) || info.Metadata["owner"] != "richa" {
+		t.Fatal("Find", info, err)
+	}
+	files, err := store.List(ctx, bson.M{"metadata.owner": "richa"})
+	if err != nil || len(files) != 1 || files[0].ID != id {
+		t.Fatal("List", files, err)
+	}
+	header := fileHeader(t, "avatar.png", "text/html", "<script>alert(1)</script>")
+	uploaded, err := store.UploadFileHeader(ctx, header, nil)
+	if err != nil {
+		t.Fatal("UploadFileHeader", err)
+	}
+	defer store.Delete(ctx, uploaded.Hex())
+	content.Reset()
+	if info, err := store.Find(ctx, uploaded.Hex()); err != nil || info.Metadata[ContentTypeMetadata] != "text/plain; charset=utf-8" {
+		t.Fatal("UploadFileHeader kept the declared content type", info, err)
+	}
+	if _, err := store.Download(ctx, uploaded.Hex(), &content); err != nil || content.String() != "<script>alert(1)</script>" {
+		t.Fatal("UploadFileHeader content", content.String(), err)
+	}
+	if err := store.Delete(ctx, id.Hex()); err != nil {
+		t.Fatal("Delete", err)
+	}
+	if _, err := store.Find(ctx, id.Hex()); !errors.Is(err, ErrNotFound) {
+		t.Fatal("Find of a deleted file", err)
+	}
+	if err := store.Delete(ctx, primitive.NewObjectID().Hex()); !errors.Is(err, ErrNotFound) {
+		t.Fatal("Delete of a missing file", err)
+	}
+	fmt.Println("FileStore- Pass")
+}
+
+// fileHeader returns the header of a file posted in a multipart form with the declared content type.
+func fileHeader(t *testing.T, filename string, contentType string, content string) *multipart.FileHeader {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Disposition": {fmt.Sprintf(`form-data; name="file"; filename=%q`, filename)},
+		"Content-Type":        {contentType},
+	})
+	if err != nil {
+		t.Fatal("CreatePart", err)
+	}
+	part.Write([]byte(content))
+	writer.Close()
+	form, err := multipart.NewReader(&body, writer.Boundary()).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatal("ReadForm", err)
+	}
+	return form.File["file"][0]
+}
@@ -0,0 +1,82 @@
+package database
+
+import (
+	"github.com/programmer-richa/utility/constants"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Health describes the server a MongoHelper is connected to.
+type Health struct {
+	// Latency is the round trip time of a ping on an established connection.
+	Latency time.Duration `json:"latency"`
+	Version string        `json:"version"`
+	// Address is the address of the server as known to its replica set.
+	Address string `json:"address,omitempty"`
+	// ReplicaSet is empty for standalone servers.
+	ReplicaSet string   `json:"replicaSet,omitempty"`
+	Primary    bool     `json:"primary"`
+	Hosts      []string `json:"hosts,omitempty"`
+}
+
+// Health connects to the deployment and reports the latency and identity of the selected server.
+// It fails if the server is unreachable before ctx is done, see ErrTimeout.
+func (m *MongoHelper) Health(ctx context.Context) (health Health, err error) {
+	defer classify(&err)
+	if m == nil {
+		return health, errors.New(constants.NilMongoHelper)
+	}
+	client, err := m.connect(ctx)
+	if err != nil {
+		return health, err
+	}
+	defer client.Disconnect(context.TODO())
+	start := time.Now()
+	if err := client.Ping(ctx, nil); err != nil {
+		return health, m.config.redactError(err)
+	}
+	health.Latency = time.Since(start)
+	admin := client.Database("admin")
+	var isMaster struct {
+		IsMaster bool     `bson:"ismaster"`
+		SetName  string   `bson:"setName"`
+		Me       string   `bson:"me"`
+		Hosts    []string `bson:"hosts"`
+	}
+	if err := admin.RunCommand(ctx, bson.D{{Key: "isMaster", Value: 1}}).Decode(&isMaster); err != nil {
+		return health, err
+	}
+	var buildInfo struct {
+		Version string `bson:"version"`
+	}
+	if err := admin.RunCommand(ctx, bson.D{{Key: "buildInfo", Value: 1}}).Decode(&buildInfo); err != nil {
+		return health, err
+	}
+	health.Version = buildInfo.Version
+	health.Address = isMaster.Me
+	health.ReplicaSet = isMaster.SetName
+	health.Primary = isMaster.IsMaster
+	health.Hosts = isMaster.Hosts
+	return health, nil
+}
+
+// HealthHandler returns a readiness probe answering with the Health of the deployment as JSON,
+// or 503 Service Unavailable and the error if it is not reached within timeout.
+func (m *MongoHelper) HealthHandler(timeout time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		health, err := m.Health(ctx)
+		w.Header().Set("Content-Type", "application/json")
+		if err != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		json.NewEncoder(w).Encode(health)
+	})
+}
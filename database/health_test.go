@@ -0,0 +1,114 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// recordedMetrics is a MetricsRecorder keeping the last connection counts and the commands.
+type recordedMetrics struct {
+	mu       sync.Mutex
+	open     int
+	inUse    int
+	commands map[string]int
+	failures int
+}
+
+func (r *recordedMetrics) ConnectionsChanged(address string, open int, inUse int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.open, r.inUse = open, inUse
+}
+
+func (r *recordedMetrics) CommandCompleted(command string, duration time.Duration, failed bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.commands == nil {
+		r.commands = make(map[string]int)
+	}
+	r.commands[command]++
+	if failed {
+		r.failures++
+	}
+}
+
+// TestMetricsPoolEvents runs several test cases to check the connection counts
+// reported by the metrics monitor defined in database package.
+func TestMetricsPoolEvents(t *testing.T) {
+	tests := []struct {
+		name   string
+		events []string
+		open   int
+		inUse  int
+	}{
+		{"Connection created", []string{event.ConnectionCreated}, 1, 0},
+		{"Connection checked out", []string{event.ConnectionCreated, event.GetSucceeded}, 1, 1},
+		{"Connection checked in", []string{event.ConnectionCreated, event.GetSucceeded, event.ConnectionReturned}, 1, 0},
+		{"Connection closed", []string{event.ConnectionCreated, event.ConnectionCreated, event.ConnectionClosed}, 1, 0},
+		{"Ignored events", []string{event.PoolCreated, event.GetFailed}, 0, 0},
+	}
+
+	for _, c := range tests {
+		t.Run(c.name, func(t *testing.T) {
+			recorder := &recordedMetrics{}
+			helper := NewMongoHelper("root", "local", "localhost", "27017", "test")
+			helper.SetMetrics(recorder)
+			for _, eventType := range c.events {
+				helper.monitor.poolEvent(&event.PoolEvent{Type: eventType, Address: "localhost:27017"})
+			}
+			if recorder.open != c.open || recorder.inUse != c.inUse {
+				t.Fatal("Metrics", c.name, recorder.open, recorder.inUse)
+			} else {
+				fmt.Println("Metrics-", c.name, "Pass")
+			}
+		})
+	}
+}
+
+// TestHealthUnreachable checks the health of a deployment that cannot be reached.
+func TestHealthUnreachable(t *testing.T) {
+	helper, err := NewMongoHelperFromURI("mongodb://127.0.0.1:1/?serverSelectionTimeoutMS=200&connectTimeoutMS=200", "test")
+	if err != nil {
+		t.Fatal("NewMongoHelperFromURI", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := helper.Health(ctx); !errors.Is(err, ErrTimeout) {
+		t.Fatal("Health", err)
+	}
+	recorder := httptest.NewRecorder()
+	helper.HealthHandler(time.Second).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Fatal("HealthHandler", recorder.Code, recorder.Body.String())
+	}
+	fmt.Println("Health unreachable- Pass")
+}
+
+// TestHealth checks the health and metrics of a live database.
+// It is skipped if the database configured in constants is unreachable.
+func TestHealth(t *testing.T) {
+	testStores(t)
+	if liveHelper == nil {
+		t.Skip("MongoDB is unreachable")
+	}
+	helper := *liveHelper
+	recorder := &recordedMetrics{}
+	helper.SetMetrics(recorder)
+	health, err := helper.Health(context.Background())
+	if err != nil || health.Version == "" || health.Latency <= 0 {
+		t.Fatal("Health", health, err)
+	}
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	if recorder.commands["ping"] == 0 || recorder.commands["buildInfo"] == 0 {
+		t.Fatal("Metrics commands", recorder.commands)
+	}
+	fmt.Println("Health- Pass")
+}
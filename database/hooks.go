@@ -0,0 +1,106 @@
+package database
+
+import (
+	"context"
+)
+
+// Hooks are implemented by models to run code around their writes.
+// A before hook returning an error cancels the write. An after hook runs once the
+// write succeeded, so its error is returned although the document was written;
+// inside WithTransaction the error rolls the write back.
+// Hooks are found on the entry passed to the store, so models with pointer receiver
+// hooks must be passed by pointer.
+type (
+	// BeforeInserter is called before the model is inserted, after its audit fields are stamped.
+	BeforeInserter interface {
+		BeforeInsert(ctx context.Context) error
+	}
+	// AfterInserter is called after the model is inserted.
+	AfterInserter interface {
+		AfterInsert(ctx context.Context) error
+	}
+	// BeforeUpdater is called before the model is updated, after its audit fields are stamped.
+	BeforeUpdater interface {
+		BeforeUpdate(ctx context.Context) error
+	}
+	// AfterUpdater is called after the model is updated.
+	AfterUpdater interface {
+		AfterUpdate(ctx context.Context) error
+	}
+	// BeforeDeleter is called before the model is deleted.
+	BeforeDeleter interface {
+		BeforeDelete(ctx context.Context) error
+	}
+	// AfterDeleter is called after the model is deleted.
+	AfterDeleter interface {
+		AfterDelete(ctx context.Context) error
+	}
+)
+
+// beforeInsert stamps the audit fields of entry and runs its BeforeInsert hook.
+func beforeInsert(ctx context.Context, entry interface{}) error {
+	if auditable, ok := entry.(Auditable); ok {
+		auditable.StampCreated(auditTime(), ActorFromContext(ctx))
+	}
+	if hook, ok := entry.(BeforeInserter); ok {
+		return hook.BeforeInsert(ctx)
+	}
+	return nil
+}
+
+// afterInsert runs the AfterInsert hook of entry.
+func afterInsert(ctx context.Context, entry interface{}) error {
+	if hook, ok := entry.(AfterInserter); ok {
+		return hook.AfterInsert(ctx)
+	}
+	return nil
+}
+
+// beforeUpdate stamps the update audit fields of entry and runs its BeforeUpdate hook.
+func beforeUpdate(ctx context.Context, entry interface{}) error {
+	if auditable, ok := entry.(Auditable); ok {
+		auditable.StampUpdated(auditTime(), ActorFromContext(ctx))
+	}
+	if hook, ok := entry.(BeforeUpdater); ok {
+		return hook.BeforeUpdate(ctx)
+	}
+	return nil
+}
+
+// entryUpdate returns the update setting the fields of entry. The update audit fields of an Auditable
+// entry are set as stamped by beforeUpdate, updatedBy being removed without actor, so the document
+// does not keep the actor of a previous update.
+func entryUpdate(ctx context.Context, entry interface{}) *Update {
+	update := NewUpdate().SetFields(entry)
+	if _, ok := entry.(Auditable); ok {
+		update.Stamp(ctx)
+		if ActorFromContext(ctx) == "" {
+			update.Unset(updatedByField)
+		}
+	}
+	return update
+}
+
+// afterUpdate runs the AfterUpdate hook of entry.
+func afterUpdate(ctx context.Context, entry interface{}) error {
+	if hook, ok := entry.(AfterUpdater); ok {
+		return hook.AfterUpdate(ctx)
+	}
+	return nil
+}
+
+// beforeDelete runs the BeforeDelete hook of entry.
+func beforeDelete(ctx context.Context, entry interface{}) error {
+	if hook, ok := entry.(BeforeDeleter); ok {
+		return hook.BeforeDelete(ctx)
+	}
+	return nil
+}
+
+// afterDelete runs the AfterDelete hook of entry.
+func afterDelete(ctx context.Context, entry interface{}) error {
+	if hook, ok := entry.(AfterDeleter); ok {
+		return hook.AfterDelete(ctx)
+	}
+	return nil
+}
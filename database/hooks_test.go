@@ -0,0 +1,222 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// errEmptyNote is returned by the hooks of Note for notes without text.
+var errEmptyNote = errors.New("note text is empty")
+
+// Note is an audited model recording the hooks run on it.
+type Note struct {
+	Id          primitive.ObjectID `bson:"_id"`
+	Text        string             `bson:"text"`
+	AuditFields `bson:",inline"`
+	hooks       []string
+}
+
+func (n *Note) BeforeInsert(ctx context.Context) error {
+	n.hooks = append(n.hooks, "BeforeInsert")
+	if n.Text == "" {
+		return errEmptyNote
+	}
+	return nil
+}
+
+func (n *Note) AfterInsert(ctx context.Context) error {
+	n.hooks = append(n.hooks, "AfterInsert")
+	return nil
+}
+
+func (n *Note) BeforeUpdate(ctx context.Context) error {
+	n.hooks = append(n.hooks, "BeforeUpdate")
+	if n.Text == "" {
+		return errEmptyNote
+	}
+	return nil
+}
+
+func (n *Note) AfterUpdate(ctx context.Context) error {
+	n.hooks = append(n.hooks, "AfterUpdate")
+	return nil
+}
+
+func (n *Note) BeforeDelete(ctx context.Context) error {
+	n.hooks = append(n.hooks, "BeforeDelete")
+	return nil
+}
+
+func (n *Note) AfterDelete(ctx context.Context) error {
+	n.hooks = append(n.hooks, "AfterDelete")
+	return nil
+}
+
+// findNote returns the stored note with the provided id.
+func findNote(store DocumentStore, id primitive.ObjectID) (Note, error) {
+	var note Note
+	data, err := store.FindDocument("store_hooks", id.Hex())
+	if err != nil {
+		return note, err
+	}
+	bsonBytes, _ := bson.Marshal(data)
+	err = bson.Unmarshal(bsonBytes, &note)
+	return note, err
+}
+
+// TestStoreHooks runs several test cases to check the correctness of
+// the audit fields and document hooks of every DocumentStore implementation.
+func TestStoreHooks(t *testing.T) {
+	for storeName, store := range testStores(t) {
+		t.Run(storeName, func(t *testing.T) {
+			defer store.RemoveCollection("store_hooks")
+			creator := WithActor(context.Background(), "richa")
+			note := &Note{Id: primitive.NewObjectID(), Text: "Draft"}
+			if _, err := store.InsertDocumentContext(creator, "store_hooks", note); err != nil {
+				t.Fatal("InsertDocument", storeName, err)
+			}
+			created, err := findNote(store, note.Id)
+			if err != nil || created.CreatedBy != "richa" || created.UpdatedBy != "richa" ||
+				created.CreatedAt.IsZero() || !created.UpdatedAt.Equal(created.CreatedAt) {
+				t.Fatal("Insert audit fields", storeName, created, err)
+			}
+			fmt.Println("Store hooks-", storeName, "Insert audit fields Pass")
+
+			if _, err := store.InsertDocument("store_hooks", &Note{Id: primitive.NewObjectID()}); err != errEmptyNote {
+				t.Fatal("BeforeInsert did not cancel the insert", storeName, err)
+			}
+			fmt.Println("Store hooks-", storeName, "Cancelled insert Pass")
+
+			editor := WithActor(context.Background(), "sahil")
+			update := &Note{Id: note.Id, Text: "Final"}
+			if err := store.UpdateDocumentContext(editor, "store_hooks", note.Id.Hex(), update); err != nil {
+				t.Fatal("UpdateDocument", storeName, err)
+			}
+			updated, err := findNote(store, note.Id)
+			if err != nil || updated.Text != "Final" || updated.CreatedBy != "richa" || updated.UpdatedBy != "sahil" ||
+				!updated.CreatedAt.Equal(created.CreatedAt) || updated.UpdatedAt.Before(created.UpdatedAt) {
+				t.Fatal("Update audit fields", storeName, updated, err)
+			}
+			fmt.Println("Store hooks-", storeName, "Update audit fields Pass")
+
+			if err := store.UpdateDocument("store_hooks", note.Id.Hex(), &Note{Id: note.Id}); err != errEmptyNote {
+				t.Fatal("BeforeUpdate did not cancel the update", storeName, err)
+			}
+			fmt.Println("Store hooks-", storeName, "Cancelled update Pass")
+
+			if err := store.DeleteDocument("store_hooks", note.Id.Hex(), note); err != nil {
+				t.Fatal("DeleteDocument", storeName, err)
+			}
+			if _, err := findNote(store, note.Id); !errors.Is(err, ErrNotFound) {
+				t.Fatal("DeleteDocument kept the document", storeName, err)
+			}
+			if err := store.DeleteDocument("store_hooks", note.Id.Hex(), nil); !errors.Is(err, ErrNotFound) {
+				t.Fatal("DeleteDocument of a missing document", storeName, err)
+			}
+			if fmt.Sprint(note.hooks) != "[BeforeInsert AfterInsert BeforeDelete AfterDelete]" ||
+				fmt.Sprint(update.hooks) != "[BeforeUpdate AfterUpdate]" {
+				t.Fatal("Hooks", storeName, note.hooks, update.hooks)
+			}
+			fmt.Println("Store hooks-", storeName, "Delete Pass")
+		})
+	}
+}
+
+// TestStoreApplyUpdateAudit runs several test cases to check the correctness of
+// the audit fields stamped by updates of every DocumentStore implementation.
+func TestStoreApplyUpdateAudit(t *testing.T) {
+	for storeName, store := range testStores(t) {
+		t.Run(storeName, func(t *testing.T) {
+			defer store.RemoveCollection("store_hooks")
+			note := &Note{Id: primitive.NewObjectID(), Text: "Draft"}
+			if _, err := store.InsertDocumentContext(WithActor(context.Background(), "richa"), "store_hooks", note); err != nil {
+				t.Fatal("InsertDocument", storeName, err)
+			}
+			created, err := findNote(store, note.Id)
+			if err != nil {
+				t.Fatal("FindDocument", storeName, err)
+			}
+
+			editor := WithActor(context.Background(), "sahil")
+			if err := store.ApplyUpdate(editor, "store_hooks", note.Id.Hex(), NewUpdate().Set("text", "Plain")); err != nil {
+				t.Fatal("ApplyUpdate", storeName, err)
+			}
+			plain, err := findNote(store, note.Id)
+			if err != nil || plain.Text != "Plain" || plain.UpdatedBy != "richa" || !plain.UpdatedAt.Equal(created.UpdatedAt) {
+				t.Fatal("ApplyUpdate without Stamp changed the audit fields", storeName, plain, err)
+			}
+			fmt.Println("ApplyUpdate audit-", storeName, "Not stamped Pass")
+
+			if err := store.ApplyUpdate(editor, "store_hooks", note.Id.Hex(), NewUpdate().Set("text", "Final").Stamp(editor)); err != nil {
+				t.Fatal("ApplyUpdate", storeName, err)
+			}
+			updated, err := findNote(store, note.Id)
+			if err != nil || updated.Text != "Final" || updated.CreatedBy != "richa" || updated.UpdatedBy != "sahil" ||
+				updated.UpdatedAt.Before(created.UpdatedAt) {
+				t.Fatal("ApplyUpdate audit fields", storeName, updated, err)
+			}
+			fmt.Println("ApplyUpdate audit-", storeName, "Stamped Pass")
+
+			masked := NewUpdate().SetFields(&Note{Text: "Masked"}, "text").Stamp(context.Background())
+			if err := store.ApplyUpdate(context.Background(), "store_hooks", note.Id.Hex(), masked); err != nil {
+				t.Fatal("ApplyUpdate", storeName, err)
+			}
+			anonymous, err := findNote(store, note.Id)
+			if err != nil || anonymous.Text != "Masked" || anonymous.UpdatedBy != "sahil" ||
+				anonymous.UpdatedAt.Before(updated.UpdatedAt) {
+				t.Fatal("ApplyUpdate stamped without actor", storeName, anonymous, err)
+			}
+			fmt.Println("ApplyUpdate audit-", storeName, "No actor Pass")
+
+			if err := store.UpdateDocument("store_hooks", note.Id.Hex(), &Note{Id: note.Id, Text: "Anonymous"}); err != nil {
+				t.Fatal("UpdateDocument", storeName, err)
+			}
+			if entry, err := findNote(store, note.Id); err != nil || entry.UpdatedBy != "" || entry.CreatedBy != "richa" {
+				t.Fatal("UpdateDocument without actor kept the previous actor", storeName, entry, err)
+			}
+			fmt.Println("ApplyUpdate audit-", storeName, "Entry without actor Pass")
+
+			if _, err := store.ApplyVersionedUpdate(editor, "store_hooks", note.Id.Hex(), 0,
+				NewUpdate().Set("text", "Versioned").Stamp(editor)); err != nil {
+				t.Fatal("ApplyVersionedUpdate", storeName, err)
+			}
+			versioned, err := findNote(store, note.Id)
+			if err != nil || versioned.UpdatedBy != "sahil" || versioned.UpdatedAt.Before(anonymous.UpdatedAt) {
+				t.Fatal("ApplyVersionedUpdate audit fields", storeName, versioned, err)
+			}
+			fmt.Println("ApplyUpdate audit-", storeName, "Versioned Pass")
+		})
+	}
+}
+
+// TestStoreApplyUpdateNotAuditable checks that updates of models without audit fields
+// only change the fields of the update.
+func TestStoreApplyUpdateNotAuditable(t *testing.T) {
+	for storeName, store := range testStores(t) {
+		t.Run(storeName, func(t *testing.T) {
+			defer store.RemoveCollection("store_plain")
+			id := primitive.NewObjectID()
+			if _, err := store.InsertDocument("store_plain", bson.M{"_id": id, "text": "Draft", "updatedBy": "import"}); err != nil {
+				t.Fatal("InsertDocument", storeName, err)
+			}
+			if err := store.ApplyUpdate(context.Background(), "store_plain", id.Hex(), NewUpdate().Set("text", "Final")); err != nil {
+				t.Fatal("ApplyUpdate", storeName, err)
+			}
+			if err := store.UpdateDocument("store_plain", id.Hex(), bson.M{"text": "Entry"}); err != nil {
+				t.Fatal("UpdateDocument", storeName, err)
+			}
+			data, err := store.FindDocument("store_plain", id.Hex())
+			bsonBytes, _ := bson.Marshal(data)
+			var document bson.M
+			bson.Unmarshal(bsonBytes, &document)
+			if err != nil || len(document) != 3 || document["text"] != "Entry" || document["updatedBy"] != "import" {
+				t.Fatal("Update of a model without audit fields", storeName, document, err)
+			}
+			fmt.Println("ApplyUpdate not auditable-", storeName, "Pass")
+		})
+	}
+}
@@ -0,0 +1,291 @@
+package database
+
+import (
+	"github.com/programmer-richa/utility/constants"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// idIndexName is the name of the index MongoDB maintains on _id. It is never dropped.
+const idIndexName = "_id_"
+
+// Index types of the keys of text and geospatial indexes.
+const (
+	TextIndexType = "text"
+	GeoIndexType  = "2dsphere"
+)
+
+// IndexDefinition declares an index of a collection.
+// Keys lists the indexed fields in order with their direction (1 or -1),
+// so a definition with several keys describes a compound index.
+// Fields of text and geospatial indexes have TextIndexType or GeoIndexType as direction.
+type IndexDefinition struct {
+	Collection string
+	Keys       bson.D
+	// Name defaults to the name MongoDB generates from the keys, e.g. email_1.
+	Name   string
+	Unique bool
+	Sparse bool
+	// TTL makes MongoDB delete documents once the indexed date is older than TTL.
+	TTL time.Duration
+	// Weights sets the weight of text fields in the score of text searches. Other text fields weigh 1.
+	Weights map[string]int32
+}
+
+// TextIndex declares the text index of collection on fields, used by TextSearch.
+// A collection has at most one text index.
+func TextIndex(collection string, fields ...string) IndexDefinition {
+	keys := make(bson.D, 0, len(fields))
+	for _, field := range fields {
+		keys = append(keys, bson.E{Key: field, Value: TextIndexType})
+	}
+	return IndexDefinition{Collection: collection, Keys: keys}
+}
+
+// GeoIndex declares a geospatial index of collection on field, which holds GeoJSON values such as Point.
+// It is needed by GeoNear and speeds up Near and Within.
+func GeoIndex(collection string, field string) IndexDefinition {
+	return IndexDefinition{Collection: collection, Keys: bson.D{{Key: field, Value: GeoIndexType}}}
+}
+
+// IndexName returns the name of the index.
+func (d IndexDefinition) IndexName() string {
+	if d.Name != "" {
+		return d.Name
+	}
+	parts := make([]string, 0, len(d.Keys))
+	for _, key := range d.Keys {
+		parts = append(parts, fmt.Sprintf("%s_%v", key.Key, key.Value))
+	}
+	return strings.Join(parts, "_")
+}
+
+// model converts the definition to a driver index model.
+func (d IndexDefinition) model() mongo.IndexModel {
+	opts := options.Index().SetName(d.IndexName())
+	if d.Unique {
+		opts.SetUnique(true)
+	}
+	if d.Sparse {
+		opts.SetSparse(true)
+	}
+	if d.TTL > 0 {
+		opts.SetExpireAfterSeconds(int32(d.TTL / time.Second))
+	}
+	if len(d.Weights) > 0 {
+		weights := bson.M{}
+		for field, weight := range d.Weights {
+			weights[field] = weight
+		}
+		opts.SetWeights(weights)
+	}
+	return mongo.IndexModel{Keys: d.Keys, Options: opts}
+}
+
+// matches tests if an index listed by the server implements the definition.
+// Keys are compared in order, as the order of the keys of a compound index decides the queries it serves.
+func (d IndexDefinition) matches(existing bson.M) bool {
+	keys, ok := existing["key"].(bson.D)
+	if !ok {
+		return false
+	}
+	weights, _ := existing["weights"].(bson.M)
+	keys = sortTextKeys(declaredKeys(keys, weights))
+	expected := sortTextKeys(d.Keys)
+	if len(keys) != len(expected) {
+		return false
+	}
+	for i, key := range expected {
+		if keys[i].Key != key.Key || !valuesEqual(keys[i].Value, normalizeIndexValue(key.Value)) {
+			return false
+		}
+		if key.Value != TextIndexType {
+			continue
+		}
+		weight, ok := d.Weights[key.Key]
+		if !ok {
+			weight = 1
+		}
+		if listed, _ := toFloat(weights[key.Key]); listed != float64(weight) {
+			return false
+		}
+	}
+	expireAfter, _ := toFloat(existing["expireAfterSeconds"])
+	return isTrue(existing["unique"]) == d.Unique &&
+		isTrue(existing["sparse"]) == d.Sparse &&
+		int64(expireAfter) == int64(d.TTL/time.Second)
+}
+
+// declaredKeys converts the keys of an index listed by the server to the form of definitions.
+// Text indexes are listed with the internal _fts and _ftsx keys, their fields being the keys of weights.
+func declaredKeys(keys bson.D, weights bson.M) bson.D {
+	declared := make(bson.D, 0, len(keys)+len(weights))
+	for _, key := range keys {
+		switch key.Key {
+		case "_fts":
+			for field := range weights {
+				declared = append(declared, bson.E{Key: field, Value: TextIndexType})
+			}
+		case "_ftsx":
+		default:
+			declared = append(declared, key)
+		}
+	}
+	return declared
+}
+
+// sortTextKeys returns keys with the text fields, whose order does not matter, sorted by name
+// at the position of the first of them.
+func sortTextKeys(keys bson.D) bson.D {
+	var text []string
+	for _, key := range keys {
+		if key.Value == TextIndexType {
+			text = append(text, key.Key)
+		}
+	}
+	sort.Strings(text)
+	sorted := make(bson.D, 0, len(keys))
+	for _, key := range keys {
+		if key.Value != TextIndexType {
+			sorted = append(sorted, key)
+		} else if len(text) > 0 {
+			for _, field := range text {
+				sorted = append(sorted, bson.E{Key: field, Value: TextIndexType})
+			}
+			text = nil
+		}
+	}
+	return sorted
+}
+
+// normalizeIndexValue converts a key direction to the type used in index listings.
+func normalizeIndexValue(value interface{}) interface{} {
+	if n, ok := value.(int); ok {
+		return int32(n)
+	}
+	return value
+}
+
+// isTrue tests if an index option is set.
+func isTrue(value interface{}) bool {
+	b, ok := value.(bool)
+	return ok && b
+}
+
+// ReconcileIndexes makes the indexes of the database match the definitions.
+// Missing indexes are created and indexes whose keys or options differ are recreated.
+// If dropUndeclared is set, other indexes of the collections named in the
+// definitions are dropped, except the _id index.
+func (m *MongoHelper) ReconcileIndexes(ctx context.Context, definitions []IndexDefinition, dropUndeclared bool) (err error) {
+	defer classify(&err)
+	if m == nil {
+		return errors.New(constants.NilMongoHelper)
+	}
+	byCollection := map[string][]IndexDefinition{}
+	for _, definition := range definitions {
+		if definition.Collection == "" || len(definition.Keys) == 0 {
+			return fmt.Errorf("%s %s", constants.InvalidIndex, definition.IndexName())
+		}
+		byCollection[definition.Collection] = append(byCollection[definition.Collection], definition)
+	}
+	client, err := m.GetSession()
+	if err != nil {
+		return err
+	}
+	// Close DB connection after this method is executed.
+	defer client.Disconnect(context.TODO())
+	for collectionName, collectionDefinitions := range byCollection {
+		collection, err := m.GetCollection(client, collectionName)
+		if err != nil {
+			return err
+		}
+		if err := reconcileCollectionIndexes(ctx, collection, collectionDefinitions, dropUndeclared); err != nil {
+			return fmt.Errorf("indexes of %s: %w", collectionName, err)
+		}
+	}
+	return nil
+}
+
+// reconcileCollectionIndexes makes the indexes of collection match the definitions.
+func reconcileCollectionIndexes(ctx context.Context, collection *mongo.Collection,
+	definitions []IndexDefinition, dropUndeclared bool) error {
+	existing, err := listIndexes(ctx, collection)
+	if err != nil {
+		return err
+	}
+	declared := map[string]bool{idIndexName: true}
+	for _, definition := range definitions {
+		name := definition.IndexName()
+		declared[name] = true
+		if index, ok := existing[name]; ok {
+			if definition.matches(index) {
+				continue
+			}
+			if _, err := collection.Indexes().DropOne(ctx, name); err != nil {
+				return err
+			}
+		}
+		if _, err := collection.Indexes().CreateOne(ctx, definition.model()); err != nil {
+			return err
+		}
+	}
+	if !dropUndeclared {
+		return nil
+	}
+	for name := range existing {
+		if declared[name] {
+			continue
+		}
+		if _, err := collection.Indexes().DropOne(ctx, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listIndexes returns the indexes of collection by name.
+// A collection that does not exist yet has no indexes.
+func listIndexes(ctx context.Context, collection *mongo.Collection) (map[string]bson.M, error) {
+	cursor, err := collection.Indexes().List(ctx)
+	if err != nil {
+		var cmdErr mongo.CommandError
+		if errors.As(err, &cmdErr) && cmdErr.Name == "NamespaceNotFound" {
+			return map[string]bson.M{}, nil
+		}
+		return nil, err
+	}
+	var indexes []bson.M
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		var index bson.M
+		if err := cursor.Decode(&index); err != nil {
+			return nil, err
+		}
+		// The keys are decoded again in order, which a bson.M loses.
+		var ordered struct {
+			Key bson.D `bson:"key"`
+		}
+		if err := cursor.Decode(&ordered); err != nil {
+			return nil, err
+		}
+		index["key"] = ordered.Key
+		indexes = append(indexes, index)
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+	byName := make(map[string]bson.M, len(indexes))
+	for _, index := range indexes {
+		if name, ok := index["name"].(string); ok {
+			byName[name] = index
+		}
+	}
+	return byName, nil
+}
@@ -0,0 +1,241 @@
+package database
+
+import (
+	"github.com/programmer-richa/utility/constants"
+	"context"
+	"errors"
+	"time"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// LockCollection is the default collection of the leases granted by a Locker.
+const LockCollection = "_locks"
+
+// lockPollInterval is the delay between two attempts of Acquire to take a held lock.
+const lockPollInterval = 500 * time.Millisecond
+
+// minLockTTL is the shortest lease, the precision of the expiry times stored by MongoDB.
+const minLockTTL = time.Millisecond
+
+// Errors returned by Locker.
+var (
+	// ErrLocked reports that the lock is held by another owner.
+	ErrLocked = errors.New(constants.LockHeld)
+	// ErrLockLost reports that a lease expired, or was released, before it was renewed or released.
+	ErrLockLost = errors.New(constants.LockLost)
+)
+
+// Lease is a lock held until ExpiresAt unless renewed.
+type Lease struct {
+	Name      string
+	Owner     string
+	ExpiresAt time.Time
+	// Token is the fencing token of the lease. Tokens increase with every acquisition
+	// of a lock, so a resource can reject the writes of a holder whose lease expired
+	// by refusing tokens lower than the highest one it has seen.
+	Token int64
+}
+
+// leaseDocument is the stored form of a Lease.
+type leaseDocument struct {
+	Name      string    `bson:"_id"`
+	Owner     string    `bson:"owner"`
+	ExpiresAt time.Time `bson:"expiresAt"`
+	// Token is a server timestamp, which increases with every write.
+	Token primitive.Timestamp `bson:"token"`
+}
+
+// fencingToken returns the fencing token of a lease acquired at the server timestamp ts.
+func fencingToken(ts primitive.Timestamp) int64 {
+	return int64(ts.T)<<32 | int64(ts.I)
+}
+
+// timestamp is the inverse of fencingToken.
+func timestamp(token int64) primitive.Timestamp {
+	return primitive.Timestamp{T: uint32(token >> 32), I: uint32(token)}
+}
+
+// Locker grants lease-based locks shared by every instance using the same collection,
+// e.g. to let a single replica send the daily digest.
+// Leases expire by themselves, so a crashed holder cannot keep a lock;
+// holders of long tasks must Renew their lease before it expires.
+// Expiry is decided with the clock of the instance, so clocks should be synchronised.
+type Locker struct {
+	helper     *MongoHelper
+	collection string
+	owner      string
+}
+
+// NewLocker returns a Locker storing its leases in collectionName, or LockCollection if it is empty.
+// Every Locker is a distinct owner, even in the same process.
+func NewLocker(helper *MongoHelper, collectionName string) (*Locker, error) {
+	if helper == nil {
+		return nil, errors.New(constants.NilMongoHelper)
+	}
+	if collectionName == "" {
+		collectionName = LockCollection
+	}
+	return &Locker{helper: helper, collection: collectionName, owner: uuid.New().String()}, nil
+}
+
+// Indexes returns the TTL index removing expired leases, to be passed to ReconcileIndexes.
+// Expired leases are ignored without it, so it only keeps the collection small.
+func (l *Locker) Indexes() []IndexDefinition {
+	return []IndexDefinition{{Collection: l.collection, Keys: bson.D{{Key: "expiresAt", Value: 1}}, TTL: time.Second}}
+}
+
+// TryAcquire takes the lock named name for ttl, which must be at least a millisecond.
+// It returns an error matching ErrLocked if another owner holds the lock.
+func (l *Locker) TryAcquire(ctx context.Context, name string, ttl time.Duration) (*Lease, error) {
+	if l == nil {
+		return nil, errors.New(constants.NilMongoHelper)
+	}
+	if ttl < minLockTTL {
+		return nil, errors.New(constants.InvalidLockTTL)
+	}
+	collection, release, err := l.helper.collection(ctx, l.collection)
+	if err != nil {
+		return nil, classifyError(err)
+	}
+	defer release()
+	now := time.Now()
+	// The filter only matches an expired lease. If the lock is held the upsert
+	// fails with a duplicate key error, as the lease document already exists.
+	var document leaseDocument
+	err = collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": name, "expiresAt": bson.M{"$lt": now}},
+		bson.M{
+			"$set":         bson.M{"owner": l.owner, "expiresAt": now.Add(ttl)},
+			"$currentDate": bson.M{"token": bson.M{"$type": "timestamp"}},
+		},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)).Decode(&document)
+	if isDuplicateKeyError(err) {
+		return nil, ErrLocked
+	}
+	if err != nil {
+		return nil, classifyError(err)
+	}
+	return &Lease{
+		Name:      document.Name,
+		Owner:     document.Owner,
+		ExpiresAt: document.ExpiresAt,
+		Token:     fencingToken(document.Token),
+	}, nil
+}
+
+// Acquire is TryAcquire waiting for the lock to be free until ctx is done,
+// in which case it returns the error of ctx.
+func (l *Locker) Acquire(ctx context.Context, name string, ttl time.Duration) (*Lease, error) {
+	for {
+		lease, err := l.TryAcquire(ctx, name, ttl)
+		if err != ErrLocked {
+			return lease, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
+
+// Renew extends the lease to expire ttl from now.
+// It returns an error matching ErrLockLost if the lease expired and the lock was taken,
+// or was released.
+func (l *Locker) Renew(ctx context.Context, lease *Lease, ttl time.Duration) error {
+	if l == nil {
+		return errors.New(constants.NilMongoHelper)
+	}
+	if ttl < minLockTTL {
+		return errors.New(constants.InvalidLockTTL)
+	}
+	collection, release, err := l.helper.collection(ctx, l.collection)
+	if err != nil {
+		return classifyError(err)
+	}
+	defer release()
+	expiresAt := time.Now().Add(ttl)
+	result, err := collection.UpdateOne(ctx, lease.filter(), bson.M{"$set": bson.M{"expiresAt": expiresAt}})
+	if err != nil {
+		return classifyError(err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrLockLost
+	}
+	lease.ExpiresAt = expiresAt
+	return nil
+}
+
+// Release frees the lock of the lease.
+// It returns an error matching ErrLockLost if the lease expired and the lock was taken,
+// or was already released.
+func (l *Locker) Release(ctx context.Context, lease *Lease) error {
+	if l == nil {
+		return errors.New(constants.NilMongoHelper)
+	}
+	collection, release, err := l.helper.collection(ctx, l.collection)
+	if err != nil {
+		return classifyError(err)
+	}
+	defer release()
+	result, err := collection.DeleteOne(ctx, lease.filter())
+	if err != nil {
+		return classifyError(err)
+	}
+	if result.DeletedCount == 0 {
+		return ErrLockLost
+	}
+	return nil
+}
+
+// WithLock runs fn while holding the lock named name, renewing the lease every ttl/2.
+// It returns an error matching ErrLocked without running fn if another owner holds the lock.
+// If a renewal fails, the context passed to fn is cancelled and ErrLockLost is returned.
+func (l *Locker) WithLock(ctx context.Context, name string, ttl time.Duration, fn func(ctx context.Context, lease *Lease) error) error {
+	lease, err := l.TryAcquire(ctx, name, ttl)
+	if err != nil {
+		return err
+	}
+	lockCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	lost := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(ttl / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-lockCtx.Done():
+				return
+			case <-ticker.C:
+				if err := l.Renew(lockCtx, lease, ttl); err != nil && lockCtx.Err() == nil {
+					close(lost)
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+	err = fn(lockCtx, lease)
+	cancel()
+	<-done
+	select {
+	case <-lost:
+		return ErrLockLost
+	default:
+	}
+	// The lease is released with the caller's context, as lockCtx is cancelled.
+	if releaseErr := l.Release(ctx, lease); err == nil {
+		err = releaseErr
+	}
+	return err
+}
+
+// filter returns the filter matching the lease while it is held.
+func (lease *Lease) filter() bson.M {
+	return bson.M{"_id": lease.Name, "owner": lease.Owner, "token": timestamp(lease.Token)}
+}
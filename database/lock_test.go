@@ -0,0 +1,102 @@
+package database
+
+import (
+	"github.com/programmer-richa/utility/constants"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TestFencingToken checks that fencing tokens follow the order of the server timestamps
+// they are derived from, and convert back to them.
+func TestFencingToken(t *testing.T) {
+	tests := []struct {
+		name  string
+		older primitive.Timestamp
+		newer primitive.Timestamp
+	}{
+		{"Same second", primitive.Timestamp{T: 100, I: 1}, primitive.Timestamp{T: 100, I: 2}},
+		{"Next second", primitive.Timestamp{T: 100, I: 4000}, primitive.Timestamp{T: 101, I: 1}},
+		{"Large ordinal", primitive.Timestamp{T: 1 << 31, I: 1 << 31}, primitive.Timestamp{T: 1<<31 + 1, I: 0}},
+	}
+
+	for _, c := range tests {
+		t.Run(c.name, func(t *testing.T) {
+			older, newer := fencingToken(c.older), fencingToken(c.newer)
+			if older >= newer || timestamp(older) != c.older || timestamp(newer) != c.newer {
+				t.Fatal("fencingToken", c.name, older, newer)
+			} else {
+				fmt.Println("fencingToken-", c.name, "Pass")
+			}
+		})
+	}
+}
+
+// TestLocker checks acquiring, renewing and releasing locks against a live database.
+// It is skipped if the database configured in constants is unreachable.
+func TestLocker(t *testing.T) {
+	testStores(t)
+	if liveHelper == nil {
+		t.Skip("MongoDB is unreachable")
+	}
+	ctx := context.Background()
+	first, err := NewLocker(liveHelper, "locker_test")
+	if err != nil {
+		t.Fatal("NewLocker", err)
+	}
+	second, _ := NewLocker(liveHelper, "locker_test")
+	defer liveHelper.RemoveCollection("locker_test")
+	lease, err := first.TryAcquire(ctx, "digest", time.Minute)
+	if err != nil {
+		t.Fatal("TryAcquire", err)
+	}
+	if _, err := second.TryAcquire(ctx, "digest", time.Minute); err != ErrLocked {
+		t.Fatal("TryAcquire held lock", err)
+	}
+	if err := first.Renew(ctx, lease, 100*time.Millisecond); err != nil {
+		t.Fatal("Renew", err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	taken, err := second.TryAcquire(ctx, "digest", time.Minute)
+	if err != nil || taken.Token <= lease.Token {
+		t.Fatal("TryAcquire expired lock", taken, err)
+	}
+	if err := first.Release(ctx, lease); err != ErrLockLost {
+		t.Fatal("Release lost lease", err)
+	}
+	if err := second.Release(ctx, taken); err != nil {
+		t.Fatal("Release", err)
+	}
+	ran := false
+	err = first.WithLock(ctx, "digest", time.Minute, func(ctx context.Context, lease *Lease) error {
+		ran = true
+		return nil
+	})
+	if err != nil || !ran {
+		t.Fatal("WithLock", err)
+	}
+	fmt.Println("Locker- Pass")
+}
+
+// TestLockTTL checks that leases shorter than a millisecond are rejected without connecting.
+func TestLockTTL(t *testing.T) {
+	locker, err := NewLocker(NewMongoHelper("root", "local", "localhost", "27017", "test"), "")
+	if err != nil {
+		t.Fatal("NewLocker", err)
+	}
+	for _, ttl := range []time.Duration{-time.Second, 0, time.Nanosecond} {
+		if _, err := locker.TryAcquire(context.Background(), "digest", ttl); err == nil || err.Error() != constants.InvalidLockTTL {
+			t.Fatal("TryAcquire", ttl, err)
+		}
+		err := locker.WithLock(context.Background(), "digest", ttl, func(ctx context.Context, lease *Lease) error {
+			t.Fatal("WithLock ran fn with ttl", ttl)
+			return nil
+		})
+		if err == nil || err.Error() != constants.InvalidLockTTL {
+			t.Fatal("WithLock", ttl, err)
+		}
+		fmt.Println("Lock TTL-", ttl, "Pass")
+	}
+}
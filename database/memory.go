@@ -0,0 +1,666 @@
+package database
+
+import (
+	"github.com/programmer-richa/utility/constants"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// duplicateKeyCode is the server error code reported for unique index violations.
+const duplicateKeyCode = 11000
+
+// memoryTransactionKey is the context key under which a MemoryStore marks its active transaction.
+type memoryTransactionKey struct{}
+
+// MemoryStore is an in-memory DocumentStore for unit tests.
+// Documents are stored in their BSON form so they decode exactly as documents read from MongoDB.
+// Filters support equality on fields and dotted paths, $in, $gt, $gte, $lt and $lte,
+// and updates support $set, $unset, $inc and $push.
+type MemoryStore struct {
+	mu          sync.Mutex
+	collections map[string][]bson.M
+	// txMu serialises transactions, as a transaction holds the snapshot to roll back to.
+	txMu sync.Mutex
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{collections: make(map[string][]bson.M)}
+}
+
+// InsertDocument inserts an entry in the specified collection.
+// An ObjectID is generated if the entry has no _id field.
+func (s *MemoryStore) InsertDocument(collectionName string, entry interface{}) (interface{}, error) {
+	return s.InsertDocumentContext(context.TODO(), collectionName, entry)
+}
+
+// InsertDocumentContext is InsertDocument executed under ctx.
+func (s *MemoryStore) InsertDocumentContext(ctx context.Context, collectionName string, entry interface{}) (id interface{}, err error) {
+	defer classify(&err)
+	if s == nil {
+		return nil, errors.New(constants.NilMongoHelper)
+	}
+	if err := beforeInsert(ctx, entry); err != nil {
+		return 0, err
+	}
+	doc, err := toDocument(entry)
+	if err != nil {
+		return 0, err
+	}
+	if _, ok := doc["_id"]; !ok {
+		doc["_id"] = primitive.NewObjectID()
+	}
+	if err := s.insert(collectionName, doc); err != nil {
+		return 0, err
+	}
+	return doc["_id"], afterInsert(ctx, entry)
+}
+
+// insert adds doc to the collection unless its _id is taken.
+func (s *MemoryStore) insert(collectionName string, doc bson.M) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.collections[collectionName] {
+		if valuesEqual(existing["_id"], doc["_id"]) {
+			return duplicateKeyError(collectionName, doc["_id"])
+		}
+	}
+	s.collections[collectionName] = append(s.collections[collectionName], doc)
+	return nil
+}
+
+// UpdateDocument updates an entry in the specified collection.
+func (s *MemoryStore) UpdateDocument(collectionName string, id string, entry interface{}) error {
+	return s.UpdateDocumentContext(context.TODO(), collectionName, id, entry)
+}
+
+// UpdateDocumentContext is UpdateDocument executed under ctx.
+func (s *MemoryStore) UpdateDocumentContext(ctx context.Context, collectionName string, id string, entry interface{}) error {
+	if err := beforeUpdate(ctx, entry); err != nil {
+		return err
+	}
+	if err := s.ApplyUpdate(ctx, collectionName, id, entryUpdate(ctx, entry)); err != nil {
+		return err
+	}
+	return afterUpdate(ctx, entry)
+}
+
+// ApplyUpdate applies update to the document with the provided id.
+func (s *MemoryStore) ApplyUpdate(ctx context.Context, collectionName string, id string, update *Update) (err error) {
+	defer classify(&err)
+	if s == nil {
+		return errors.New(constants.NilMongoHelper)
+	}
+	if update == nil || update.IsEmpty() {
+		return errors.New(constants.InvalidUpdate)
+	}
+	docID, err := objectID(id)
+	if err != nil {
+		return err
+	}
+	document, err := toDocument(update.Document())
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, doc := range s.collections[collectionName] {
+		if valuesEqual(doc["_id"], docID) {
+			updated, err := applyUpdate(doc, document)
+			if err != nil {
+				return err
+			}
+			s.collections[collectionName][i] = updated
+			return nil
+		}
+	}
+	return mongo.ErrNoDocuments
+}
+
+// UpdateDocumentVersioned is UpdateDocumentContext for versioned documents.
+func (s *MemoryStore) UpdateDocumentVersioned(ctx context.Context, collectionName string, id string,
+	version int64, entry interface{}) (int64, error) {
+	if err := beforeUpdate(ctx, entry); err != nil {
+		return 0, err
+	}
+	newVersion, err := s.ApplyVersionedUpdate(ctx, collectionName, id, version, entryUpdate(ctx, entry))
+	if err != nil {
+		return 0, err
+	}
+	return newVersion, afterUpdate(ctx, entry)
+}
+
+// ApplyVersionedUpdate applies update to the document with the provided id if the document is at version.
+// It returns a *ConflictError if the document is at another version.
+func (s *MemoryStore) ApplyVersionedUpdate(ctx context.Context, collectionName string, id string,
+	version int64, update *Update) (newVersion int64, err error) {
+	defer classify(&err)
+	if s == nil {
+		return 0, errors.New(constants.NilMongoHelper)
+	}
+	if update == nil {
+		return 0, errors.New(constants.InvalidUpdate)
+	}
+	docID, err := objectID(id)
+	if err != nil {
+		return 0, err
+	}
+	document, err := toDocument(update.versioned().Document())
+	if err != nil {
+		return 0, err
+	}
+	filter, err := toDocument(bson.M{VersionField: versionCondition(version)})
+	if err != nil {
+		return 0, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, doc := range s.collections[collectionName] {
+		if !valuesEqual(doc["_id"], docID) {
+			continue
+		}
+		matched, err := matchFilter(doc, filter)
+		if err != nil {
+			return 0, err
+		}
+		if !matched {
+			return 0, &ConflictError{Collection: collectionName, ID: id, Expected: version}
+		}
+		updated, err := applyUpdate(doc, document)
+		if err != nil {
+			return 0, err
+		}
+		s.collections[collectionName][i] = updated
+		return version + 1, nil
+	}
+	return 0, mongo.ErrNoDocuments
+}
+
+// IsExistingDocument tests the existence of a record with the provided conditions.
+func (s *MemoryStore) IsExistingDocument(collectionName string, condition bson.M) (bool, error) {
+	return s.IsExistingDocumentContext(context.TODO(), collectionName, condition)
+}
+
+// IsExistingDocumentContext is IsExistingDocument executed under ctx.
+func (s *MemoryStore) IsExistingDocumentContext(ctx context.Context, collectionName string, condition bson.M) (bool, error) {
+	if s == nil {
+		return false, errors.New(constants.NilMongoClient)
+	}
+	doc, err := s.findOne(collectionName, condition)
+	return doc != nil, err
+}
+
+// GetaRecord returns a record that satisfies the provided conditions.
+// It returns an error matching ErrNotFound if the record is unavailable in the collection.
+func (s *MemoryStore) GetaRecord(collectionName string, condition bson.M) (interface{}, error) {
+	return s.GetaRecordContext(context.TODO(), collectionName, condition)
+}
+
+// GetaRecordContext is GetaRecord executed under ctx.
+func (s *MemoryStore) GetaRecordContext(ctx context.Context, collectionName string, condition bson.M) (record interface{}, err error) {
+	defer classify(&err)
+	if s == nil {
+		return nil, errors.New(constants.NilMongoHelper)
+	}
+	doc, err := s.findOne(collectionName, condition)
+	if err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		return nil, mongo.ErrNoDocuments
+	}
+	err = decodeDocument(doc, &record)
+	return record, err
+}
+
+// FindDocument returns the record with the provided id.
+// It returns an error matching ErrNotFound if the record is unavailable in the collection.
+func (s *MemoryStore) FindDocument(collectionName string, id string) (interface{}, error) {
+	return s.FindDocumentContext(context.TODO(), collectionName, id)
+}
+
+// FindDocumentContext is FindDocument executed under ctx.
+func (s *MemoryStore) FindDocumentContext(ctx context.Context, collectionName string, id string) (record interface{}, err error) {
+	defer classify(&err)
+	if s == nil {
+		return nil, errors.New(constants.NilMongoHelper)
+	}
+	docID, err := objectID(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.GetaRecordContext(ctx, collectionName, bson.M{"_id": docID})
+}
+
+// DeleteDocument deletes the document with the provided id and runs the delete hooks of entry, which may be nil.
+// It returns an error matching ErrNotFound if there is no document with the id.
+func (s *MemoryStore) DeleteDocument(collectionName string, id string, entry interface{}) error {
+	return s.DeleteDocumentContext(context.TODO(), collectionName, id, entry)
+}
+
+// DeleteDocumentContext is DeleteDocument executed under ctx.
+func (s *MemoryStore) DeleteDocumentContext(ctx context.Context, collectionName string, id string, entry interface{}) (err error) {
+	defer classify(&err)
+	if s == nil {
+		return errors.New(constants.NilMongoHelper)
+	}
+	docID, err := objectID(id)
+	if err != nil {
+		return err
+	}
+	if err := beforeDelete(ctx, entry); err != nil {
+		return err
+	}
+	if !s.delete(collectionName, docID) {
+		return mongo.ErrNoDocuments
+	}
+	return afterDelete(ctx, entry)
+}
+
+// delete removes the document with the provided id from the collection and reports whether it existed.
+func (s *MemoryStore) delete(collectionName string, id primitive.ObjectID) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	docs := s.collections[collectionName]
+	for i, doc := range docs {
+		if valuesEqual(doc["_id"], id) {
+			s.collections[collectionName] = append(docs[:i:i], docs[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// RemoveCollection deletes the collection and its documents.
+func (s *MemoryStore) RemoveCollection(collectionName string) error {
+	return s.RemoveCollectionContext(context.TODO(), collectionName)
+}
+
+// RemoveCollectionContext is RemoveCollection executed under ctx.
+func (s *MemoryStore) RemoveCollectionContext(ctx context.Context, collectionName string) error {
+	if s == nil {
+		return errors.New(constants.NilMongoHelper)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.collections, collectionName)
+	return nil
+}
+
+// WithTransaction runs fn and restores the previous state of the store if fn returns an error.
+// Transactions are serialised; operations outside of them are not isolated from their changes.
+func (s *MemoryStore) WithTransaction(ctx context.Context, fn TransactionFunc, opts ...*options.TransactionOptions) error {
+	if s == nil {
+		return errors.New(constants.NilMongoHelper)
+	}
+	if fn == nil {
+		return errors.New(constants.NilTransactionFunc)
+	}
+	if ctx.Value(memoryTransactionKey{}) == s {
+		return fn(ctx)
+	}
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+	s.mu.Lock()
+	snapshot := make(map[string][]bson.M, len(s.collections))
+	for name, docs := range s.collections {
+		snapshot[name] = append([]bson.M(nil), docs...)
+	}
+	s.mu.Unlock()
+	if err := fn(context.WithValue(ctx, memoryTransactionKey{}, s)); err != nil {
+		s.mu.Lock()
+		s.collections = snapshot
+		s.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// findOne returns the first document of the collection matching condition, or nil.
+func (s *MemoryStore) findOne(collectionName string, condition bson.M) (bson.M, error) {
+	filter, err := toDocument(condition)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, doc := range s.collections[collectionName] {
+		matched, err := matchFilter(doc, filter)
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			return doc, nil
+		}
+	}
+	return nil, nil
+}
+
+// toDocument converts v to the bson.M form it has once stored in MongoDB,
+// e.g. time.Time values become primitive.DateTime and int values int32 or int64.
+func toDocument(v interface{}) (bson.M, error) {
+	data, err := bson.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	doc := bson.M{}
+	err = bson.Unmarshal(data, &doc)
+	return doc, err
+}
+
+// decodeDocument decodes doc into v the way the driver decodes query results.
+func decodeDocument(doc bson.M, v interface{}) error {
+	data, err := bson.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return bson.Unmarshal(data, v)
+}
+
+// duplicateKeyError returns the error reported by MongoDB for a duplicate _id.
+func duplicateKeyError(collectionName string, id interface{}) error {
+	return mongo.WriteException{
+		WriteErrors: mongo.WriteErrors{{
+			Code:    duplicateKeyCode,
+			Message: fmt.Sprintf("E11000 duplicate key error collection: %s index: _id_ dup key: { _id: %v }", collectionName, id),
+		}},
+	}
+}
+
+// applyUpdate returns a copy of doc with the update operators applied.
+func applyUpdate(doc bson.M, update bson.M) (bson.M, error) {
+	updated, err := copyDocument(doc)
+	if err != nil {
+		return nil, err
+	}
+	for operator, operand := range update {
+		fields, ok := operand.(bson.M)
+		if !ok {
+			return nil, errors.New(constants.InvalidUpdate)
+		}
+		for path, value := range fields {
+			if err := applyOperator(updated, operator, path, value); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return updated, nil
+}
+
+// applyOperator applies a single update operator to the field at path.
+func applyOperator(doc bson.M, operator string, path string, value interface{}) error {
+	switch operator {
+	case "$set":
+		setPath(doc, path, value)
+	case "$unset":
+		unsetPath(doc, path)
+	case "$inc":
+		current, found := lookupPath(doc, path)
+		if !found {
+			setPath(doc, path, value)
+			return nil
+		}
+		sum, ok := addNumbers(current, value)
+		if !ok {
+			return errors.New(constants.InvalidUpdate + " " + operator + " " + path)
+		}
+		setPath(doc, path, sum)
+	case "$push":
+		current, found := lookupPath(doc, path)
+		values, ok := current.(primitive.A)
+		if found && !ok {
+			return errors.New(constants.InvalidUpdate + " " + operator + " " + path)
+		}
+		if each, ok := value.(bson.M); ok && len(each) == 1 && each["$each"] != nil {
+			items, _ := each["$each"].(primitive.A)
+			values = append(values, items...)
+		} else {
+			values = append(values, value)
+		}
+		setPath(doc, path, values)
+	default:
+		return errors.New(constants.UnsupportedOperator + " " + operator)
+	}
+	return nil
+}
+
+// addNumbers returns the sum of two numbers, keeping integers if both are integers.
+func addNumbers(a interface{}, b interface{}) (interface{}, bool) {
+	x, okX := toFloat(a)
+	y, okY := toFloat(b)
+	if !okX || !okY {
+		return nil, false
+	}
+	_, floatX := a.(float64)
+	_, floatY := b.(float64)
+	switch {
+	case floatX || floatY:
+		return x + y, true
+	case reflect.TypeOf(a) == reflect.TypeOf(int32(0)) && reflect.TypeOf(b) == reflect.TypeOf(int32(0)):
+		return a.(int32) + b.(int32), true
+	}
+	return int64(x) + int64(y), true
+}
+
+// copyDocument returns a deep copy of doc.
+func copyDocument(doc bson.M) (bson.M, error) {
+	return toDocument(doc)
+}
+
+// setPath sets the value of the dotted path in doc, creating embedded documents as needed.
+func setPath(doc bson.M, path string, value interface{}) {
+	keys := strings.Split(path, ".")
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := doc[key].(bson.M)
+		if !ok {
+			next = bson.M{}
+			doc[key] = next
+		}
+		doc = next
+	}
+	doc[keys[len(keys)-1]] = value
+}
+
+// unsetPath removes the field at the dotted path from doc.
+func unsetPath(doc bson.M, path string) {
+	keys := strings.Split(path, ".")
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := doc[key].(bson.M)
+		if !ok {
+			return
+		}
+		doc = next
+	}
+	delete(doc, keys[len(keys)-1])
+}
+
+// lookupPath returns the value of the dotted path in doc.
+func lookupPath(doc bson.M, path string) (interface{}, bool) {
+	var value interface{} = doc
+	for _, key := range strings.Split(path, ".") {
+		embedded, ok := value.(bson.M)
+		if !ok {
+			return nil, false
+		}
+		if value, ok = embedded[key]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// matchFilter tests if doc satisfies every condition of filter.
+func matchFilter(doc bson.M, filter bson.M) (bool, error) {
+	for path, condition := range filter {
+		if strings.HasPrefix(path, "$") {
+			return false, errors.New(constants.UnsupportedOperator + " " + path)
+		}
+		value, found := lookupPath(doc, path)
+		matched, err := matchCondition(value, found, condition)
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// matchCondition tests if a field value satisfies a condition, which is either
+// a value to compare with or a document of query operators.
+// Like MongoDB, a missing field equals null.
+func matchCondition(value interface{}, found bool, condition interface{}) (bool, error) {
+	operators, ok := condition.(bson.M)
+	if !ok || len(operators) == 0 || !isOperatorDocument(operators) {
+		return (found && matchEqual(value, condition)) || (!found && condition == nil), nil
+	}
+	for operator, operand := range operators {
+		var matched bool
+		switch operator {
+		case "$in":
+			candidates, ok := operand.(primitive.A)
+			if !ok {
+				return false, errors.New(constants.InvalidFilter + " " + operator)
+			}
+			for _, candidate := range candidates {
+				if (found && matchEqual(value, candidate)) || (!found && candidate == nil) {
+					matched = true
+					break
+				}
+			}
+		case "$gt", "$gte", "$lt", "$lte":
+			matched = found && matchComparison(value, operator, operand)
+		default:
+			return false, errors.New(constants.UnsupportedOperator + " " + operator)
+		}
+		if !matched {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// isOperatorDocument tests if every key of doc is a query operator.
+func isOperatorDocument(doc bson.M) bool {
+	for key := range doc {
+		if !strings.HasPrefix(key, "$") {
+			return false
+		}
+	}
+	return true
+}
+
+// matchEqual tests if value equals target or, like MongoDB, is an array containing target.
+func matchEqual(value interface{}, target interface{}) bool {
+	if valuesEqual(value, target) {
+		return true
+	}
+	if values, ok := value.(primitive.A); ok {
+		for _, v := range values {
+			if valuesEqual(v, target) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchComparison tests if value compares to operand as required by operator.
+// Like MongoDB, values of different types never match.
+func matchComparison(value interface{}, operator string, operand interface{}) bool {
+	if values, ok := value.(primitive.A); ok {
+		for _, v := range values {
+			if matchComparison(v, operator, operand) {
+				return true
+			}
+		}
+		return false
+	}
+	cmp, ok := compareValues(value, operand)
+	if !ok {
+		return false
+	}
+	switch operator {
+	case "$gt":
+		return cmp > 0
+	case "$gte":
+		return cmp >= 0
+	case "$lt":
+		return cmp < 0
+	default:
+		return cmp <= 0
+	}
+}
+
+// valuesEqual tests if two stored values are equal, treating all numeric types alike.
+func valuesEqual(a interface{}, b interface{}) bool {
+	if cmp, ok := compareValues(a, b); ok {
+		return cmp == 0
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// compareValues orders two values of the same kind.
+// It returns false if the values are not comparable.
+func compareValues(a interface{}, b interface{}) (int, bool) {
+	if x, ok := toFloat(a); ok {
+		y, ok := toFloat(b)
+		if !ok {
+			return 0, false
+		}
+		return compareFloats(x, y), true
+	}
+	switch x := a.(type) {
+	case string:
+		y, ok := b.(string)
+		if !ok {
+			return 0, false
+		}
+		return strings.Compare(x, y), true
+	case primitive.DateTime:
+		y, ok := b.(primitive.DateTime)
+		if !ok {
+			return 0, false
+		}
+		return compareFloats(float64(x), float64(y)), true
+	case primitive.ObjectID:
+		y, ok := b.(primitive.ObjectID)
+		if !ok {
+			return 0, false
+		}
+		return strings.Compare(x.Hex(), y.Hex()), true
+	}
+	return 0, false
+}
+
+// toFloat converts a numeric value to float64.
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// compareFloats returns -1, 0 or 1 as x is less than, equal to or greater than y.
+func compareFloats(x float64, y float64) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
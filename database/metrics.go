@@ -0,0 +1,86 @@
+package database
+
+import (
+	"context"
+	"sync"
+	"time"
+	"go.mongodb.org/mongo-driver/event"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MetricsRecorder receives the connection and command metrics of a MongoHelper,
+// to be forwarded to a metrics system such as Prometheus or StatsD.
+// Its methods are called from the driver while operations run, so they must be
+// safe for concurrent use and must not block.
+type MetricsRecorder interface {
+	// ConnectionsChanged reports the number of open connections to the server at address,
+	// and how many of them are in use by an operation.
+	ConnectionsChanged(address string, open int, inUse int)
+	// CommandCompleted reports the duration of a command, such as "find" or "insert",
+	// and whether it failed.
+	CommandCompleted(command string, duration time.Duration, failed bool)
+}
+
+// SetMetrics reports the metrics of every db session created by the helper to recorder.
+// Connection counts cover all the sessions of the helper. It must be called before the helper is used.
+func (m *MongoHelper) SetMetrics(recorder MetricsRecorder) {
+	if recorder == nil {
+		m.monitor = nil
+		return
+	}
+	m.monitor = &metricsMonitor{recorder: recorder, pools: make(map[string]*poolCounts)}
+}
+
+// poolCounts holds the connections to a server.
+type poolCounts struct {
+	open  int
+	inUse int
+}
+
+// metricsMonitor turns driver monitoring events into MetricsRecorder calls.
+type metricsMonitor struct {
+	recorder MetricsRecorder
+	mu       sync.Mutex
+	pools    map[string]*poolCounts
+}
+
+// apply adds the monitors to the options of a new client.
+func (mm *metricsMonitor) apply(clientOptions *options.ClientOptions) {
+	clientOptions.SetPoolMonitor(&event.PoolMonitor{Event: mm.poolEvent})
+	clientOptions.SetMonitor(&event.CommandMonitor{
+		Succeeded: func(ctx context.Context, e *event.CommandSucceededEvent) {
+			mm.recorder.CommandCompleted(e.CommandName, time.Duration(e.DurationNanos), false)
+		},
+		Failed: func(ctx context.Context, e *event.CommandFailedEvent) {
+			mm.recorder.CommandCompleted(e.CommandName, time.Duration(e.DurationNanos), true)
+		},
+	})
+}
+
+// poolEvent updates the connection counts of the server of a pool event.
+func (mm *metricsMonitor) poolEvent(e *event.PoolEvent) {
+	var open, inUse int
+	switch e.Type {
+	case event.ConnectionCreated:
+		open = 1
+	case event.ConnectionClosed:
+		open = -1
+	case event.GetSucceeded:
+		inUse = 1
+	case event.ConnectionReturned:
+		inUse = -1
+	default:
+		return
+	}
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	counts, ok := mm.pools[e.Address]
+	if !ok {
+		counts = &poolCounts{}
+		mm.pools[e.Address] = counts
+	}
+	counts.open += open
+	counts.inUse += inUse
+	// The recorder is called under the lock so it receives the counts in order.
+	mm.recorder.ConnectionsChanged(e.Address, counts.open, counts.inUse)
+}
@@ -0,0 +1,278 @@
+package database
+
+import (
+	"github.com/programmer-richa/utility/constants"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Collections used to record applied migrations and to lock the migrator.
+const (
+	MigrationCollection     = "_migrations"
+	MigrationLockCollection = "_migrations_lock"
+)
+
+// migrationLockID identifies the single lock document.
+const migrationLockID = "migrations"
+
+// Default lock settings of a Migrator.
+const (
+	defaultMigrationLockTTL   = 10 * time.Minute
+	migrationLockPollInterval = 500 * time.Millisecond
+)
+
+// MigrationFunc changes the schema or data of db.
+type MigrationFunc func(ctx context.Context, db *mongo.Database) error
+
+// Migration is a versioned change of the database.
+// Down reverts the change of Up and may be nil if the change is irreversible.
+type Migration struct {
+	Version     int
+	Description string
+	Up          MigrationFunc
+	Down        MigrationFunc
+}
+
+// MigrationRecord is the entry stored in MigrationCollection for an applied migration.
+type MigrationRecord struct {
+	Version     int       `bson:"_id"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"appliedAt"`
+}
+
+// Migrator applies migrations in version order and records them in MigrationCollection.
+// A lease stored in MigrationLockCollection prevents several instances from migrating concurrently.
+type Migrator struct {
+	helper     *MongoHelper
+	migrations []Migration
+	owner      string
+	// LockTTL is the lifetime of the lock, after which it is considered abandoned.
+	// The lock is renewed every LockTTL/2 while the migrations run. If a renewal fails,
+	// the context passed to the migrations is cancelled and ErrLockLost is returned.
+	LockTTL time.Duration
+}
+
+// NewMigrator returns a Migrator for the provided migrations.
+// It returns an error if a version is not positive, is repeated or has no Up function.
+func NewMigrator(helper *MongoHelper, migrations ...Migration) (*Migrator, error) {
+	if helper == nil {
+		return nil, errors.New(constants.NilMongoHelper)
+	}
+	sorted := append([]Migration(nil), migrations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+	for i, migration := range sorted {
+		if migration.Version <= 0 || migration.Up == nil ||
+			(i > 0 && sorted[i-1].Version == migration.Version) {
+			return nil, fmt.Errorf("%s %d", constants.InvalidMigration, migration.Version)
+		}
+	}
+	return &Migrator{
+		helper:     helper,
+		migrations: sorted,
+		owner:      primitive.NewObjectID().Hex(),
+		LockTTL:    defaultMigrationLockTTL,
+	}, nil
+}
+
+// Up applies every migration that has not been applied yet.
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.UpTo(ctx, m.latestVersion())
+}
+
+// UpTo applies the pending migrations up to and including version.
+func (m *Migrator) UpTo(ctx context.Context, version int) error {
+	return m.run(ctx, func(ctx context.Context, db *mongo.Database, applied map[int]bool) error {
+		records := db.Collection(MigrationCollection)
+		for _, migration := range m.migrations {
+			if migration.Version > version || applied[migration.Version] {
+				continue
+			}
+			if err := migration.Up(ctx, db); err != nil {
+				return fmt.Errorf("migration %d: %w", migration.Version, err)
+			}
+			record := MigrationRecord{migration.Version, migration.Description, time.Now().UTC()}
+			if _, err := records.InsertOne(ctx, record); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Down reverts the applied migrations newer than version, latest first.
+// Use a version of 0 to revert every migration.
+func (m *Migrator) Down(ctx context.Context, version int) error {
+	return m.run(ctx, func(ctx context.Context, db *mongo.Database, applied map[int]bool) error {
+		records := db.Collection(MigrationCollection)
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			migration := m.migrations[i]
+			if migration.Version <= version || !applied[migration.Version] {
+				continue
+			}
+			if migration.Down == nil {
+				return fmt.Errorf("%s %d", constants.IrreversibleMigration, migration.Version)
+			}
+			if err := migration.Down(ctx, db); err != nil {
+				return fmt.Errorf("migration %d: %w", migration.Version, err)
+			}
+			if _, err := records.DeleteOne(ctx, bson.M{"_id": migration.Version}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Applied returns the records of the applied migrations in version order.
+func (m *Migrator) Applied(ctx context.Context) ([]MigrationRecord, error) {
+	client, err := m.helper.GetSession()
+	if err != nil {
+		return nil, err
+	}
+	// Close DB connection after this method is executed.
+	defer client.Disconnect(context.TODO())
+	db, err := m.helper.GetDatabase(client)
+	if err != nil {
+		return nil, err
+	}
+	return appliedMigrations(ctx, db)
+}
+
+// Version returns the version of the latest applied migration, or 0 if none was applied.
+func (m *Migrator) Version(ctx context.Context) (int, error) {
+	records, err := m.Applied(ctx)
+	if err != nil || len(records) == 0 {
+		return 0, err
+	}
+	return records[len(records)-1].Version, nil
+}
+
+// latestVersion returns the highest version known to the migrator.
+func (m *Migrator) latestVersion() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// run executes fn while holding the migration lock, renewing it every LockTTL/2.
+// fn receives the set of versions applied before it runs, and a context cancelled if the lock is lost.
+func (m *Migrator) run(ctx context.Context, fn func(ctx context.Context, db *mongo.Database, applied map[int]bool) error) error {
+	if m.LockTTL < minLockTTL {
+		return errors.New(constants.InvalidLockTTL)
+	}
+	client, err := m.helper.GetSession()
+	if err != nil {
+		return err
+	}
+	// Close DB connection after this method is executed.
+	defer client.Disconnect(context.TODO())
+	db, err := m.helper.GetDatabase(client)
+	if err != nil {
+		return err
+	}
+	if err := m.lock(ctx, db); err != nil {
+		return err
+	}
+	// Release the lock even if ctx is cancelled meanwhile.
+	defer db.Collection(MigrationLockCollection).DeleteOne(context.TODO(),
+		bson.M{"_id": migrationLockID, "owner": m.owner})
+	records, err := appliedMigrations(ctx, db)
+	if err != nil {
+		return err
+	}
+	applied := make(map[int]bool, len(records))
+	for _, record := range records {
+		applied[record.Version] = true
+	}
+	lockCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	lost := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(m.LockTTL / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-lockCtx.Done():
+				return
+			case <-ticker.C:
+				if err := m.renew(lockCtx, db); err != nil && lockCtx.Err() == nil {
+					close(lost)
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+	err = fn(lockCtx, db, applied)
+	cancel()
+	<-done
+	select {
+	case <-lost:
+		return ErrLockLost
+	default:
+	}
+	return err
+}
+
+// lock acquires the migration lock, waiting while another instance holds it until ctx is done,
+// in which case it returns the error of ctx.
+func (m *Migrator) lock(ctx context.Context, db *mongo.Database) error {
+	locks := db.Collection(MigrationLockCollection)
+	for {
+		now := time.Now()
+		// The filter only matches an expired lock. If the lock is held the upsert
+		// fails with a duplicate key error, as the lock document already exists.
+		_, err := locks.UpdateOne(ctx,
+			bson.M{"_id": migrationLockID, "expiresAt": bson.M{"$lt": now}},
+			bson.M{"$set": bson.M{"owner": m.owner, "expiresAt": now.Add(m.LockTTL)}},
+			options.Update().SetUpsert(true))
+		if err == nil {
+			return nil
+		}
+		if !isDuplicateKeyError(err) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(migrationLockPollInterval):
+		}
+	}
+}
+
+// renew extends the migration lock held by the migrator to expire LockTTL from now.
+// It returns ErrLockLost if the lock expired and was taken by another instance.
+func (m *Migrator) renew(ctx context.Context, db *mongo.Database) error {
+	result, err := db.Collection(MigrationLockCollection).UpdateOne(ctx,
+		bson.M{"_id": migrationLockID, "owner": m.owner},
+		bson.M{"$set": bson.M{"expiresAt": time.Now().Add(m.LockTTL)}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrLockLost
+	}
+	return nil
+}
+
+// appliedMigrations returns the records stored in MigrationCollection in version order.
+func appliedMigrations(ctx context.Context, db *mongo.Database) ([]MigrationRecord, error) {
+	cursor, err := db.Collection(MigrationCollection).Find(ctx, bson.M{},
+		options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	records := []MigrationRecord{}
+	err = cursor.All(ctx, &records)
+	return records, err
+}
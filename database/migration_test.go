@@ -0,0 +1,254 @@
+package database
+
+import (
+	"github.com/programmer-richa/utility/constants"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// noopMigration is a MigrationFunc that changes nothing.
+func noopMigration(ctx context.Context, db *mongo.Database) error {
+	return nil
+}
+
+// TestNewMigrator runs several test cases to check the correctness of
+// the migration validation functionality defined in database package.
+func TestNewMigrator(t *testing.T) {
+	helper := NewMongoHelper("root", "local", "localhost", "27017", "test")
+	tests := []struct {
+		name       string
+		helper     *MongoHelper
+		migrations []Migration
+		latest     int
+		valid      bool
+	}{
+		{
+			"Nil value",
+			nil,
+			nil,
+			0,
+			false,
+		}, {
+			"Unordered migrations",
+			helper,
+			[]Migration{{Version: 3, Up: noopMigration}, {Version: 1, Up: noopMigration}},
+			3,
+			true,
+		}, {
+			"Repeated version",
+			helper,
+			[]Migration{{Version: 1, Up: noopMigration}, {Version: 1, Up: noopMigration}},
+			0,
+			false,
+		}, {
+			"Missing Up function",
+			helper,
+			[]Migration{{Version: 1}},
+			0,
+			false,
+		}, {
+			"Non positive version",
+			helper,
+			[]Migration{{Version: 0, Up: noopMigration}},
+			0,
+			false,
+		},
+	}
+
+	for _, c := range tests {
+		t.Run(c.name, func(t *testing.T) {
+			migrator, err := NewMigrator(c.helper, c.migrations...)
+			if (err == nil) != c.valid || (c.valid && migrator.latestVersion() != c.latest) {
+				t.Fatal("NewMigrator Function ", c.name, err)
+			} else {
+				fmt.Println("NewMigrator Function-", c.name, "Pass")
+			}
+		})
+	}
+}
+
+// TestIndexDefinition runs several test cases to check the correctness of
+// the index comparison functionality defined in database package.
+func TestIndexDefinition(t *testing.T) {
+	tests := []struct {
+		name       string
+		definition IndexDefinition
+		existing   bson.M
+		indexName  string
+		matches    bool
+	}{
+		{
+			"Unique index",
+			IndexDefinition{Collection: "users", Keys: bson.D{{Key: "email", Value: 1}}, Unique: true},
+			bson.M{"name": "email_1", "key": bson.D{{Key: "email", Value: int32(1)}}, "unique": true},
+			"email_1",
+			true,
+		}, {
+			"Unique option missing",
+			IndexDefinition{Collection: "users", Keys: bson.D{{Key: "email", Value: 1}}, Unique: true},
+			bson.M{"name": "email_1", "key": bson.D{{Key: "email", Value: int32(1)}}},
+			"email_1",
+			false,
+		}, {
+			"Compound index",
+			IndexDefinition{Collection: "orders", Keys: bson.D{{Key: "user", Value: 1}, {Key: "createdAt", Value: -1}}},
+			bson.M{"name": "user_1_createdAt_-1", "key": bson.D{{Key: "user", Value: int32(1)}, {Key: "createdAt", Value: int32(-1)}}},
+			"user_1_createdAt_-1",
+			true,
+		}, {
+			"Changed key order",
+			IndexDefinition{Collection: "orders", Name: "user_created", Keys: bson.D{{Key: "user", Value: 1}, {Key: "createdAt", Value: -1}}},
+			bson.M{"name": "user_created", "key": bson.D{{Key: "createdAt", Value: int32(-1)}, {Key: "user", Value: int32(1)}}},
+			"user_created",
+			false,
+		}, {
+			"Changed direction",
+			IndexDefinition{Collection: "orders", Keys: bson.D{{Key: "user", Value: 1}, {Key: "createdAt", Value: 1}}},
+			bson.M{"name": "user_1_createdAt_-1", "key": bson.D{{Key: "user", Value: int32(1)}, {Key: "createdAt", Value: int32(-1)}}},
+			"user_1_createdAt_1",
+			false,
+		}, {
+			"TTL index",
+			IndexDefinition{Collection: "sessions", Name: "expiry", Keys: bson.D{{Key: "createdAt", Value: 1}}, TTL: time.Hour},
+			bson.M{"name": "expiry", "key": bson.D{{Key: "createdAt", Value: int32(1)}}, "expireAfterSeconds": int32(3600)},
+			"expiry",
+			true,
+		}, {
+			"Changed TTL",
+			IndexDefinition{Collection: "sessions", Name: "expiry", Keys: bson.D{{Key: "createdAt", Value: 1}}, TTL: time.Minute},
+			bson.M{"name": "expiry", "key": bson.D{{Key: "createdAt", Value: int32(1)}}, "expireAfterSeconds": int32(3600)},
+			"expiry",
+			false,
+		}, {
+			"Text index",
+			IndexDefinition{Collection: "products", Keys: bson.D{{Key: "name", Value: TextIndexType}, {Key: "description", Value: TextIndexType}},
+				Weights: map[string]int32{"name": 5}},
+			bson.M{"name": "name_text_description_text", "key": bson.D{{Key: "_fts", Value: "text"}, {Key: "_ftsx", Value: int32(1)}},
+				"weights": bson.M{"name": int32(5), "description": int32(1)}},
+			"name_text_description_text",
+			true,
+		}, {
+			"Changed text weight",
+			TextIndex("products", "name", "description"),
+			bson.M{"name": "name_text_description_text", "key": bson.D{{Key: "_fts", Value: "text"}, {Key: "_ftsx", Value: int32(1)}},
+				"weights": bson.M{"name": int32(5), "description": int32(1)}},
+			"name_text_description_text",
+			false,
+		}, {
+			"Geo index",
+			GeoIndex("stores", "location"),
+			bson.M{"name": "location_2dsphere", "key": bson.D{{Key: "location", Value: "2dsphere"}}, "2dsphereIndexVersion": int32(3)},
+			"location_2dsphere",
+			true,
+		},
+	}
+
+	for _, c := range tests {
+		t.Run(c.name, func(t *testing.T) {
+			if c.definition.IndexName() != c.indexName || c.definition.matches(c.existing) != c.matches {
+				t.Fatal("IndexDefinition", c.name, c.definition.IndexName())
+			} else {
+				fmt.Println("IndexDefinition-", c.name, "Pass")
+			}
+		})
+	}
+}
+
+// TestMigratorUpDown checks applying and reverting migrations against a live database.
+// It is skipped if the database configured in constants is unreachable.
+func TestMigratorUpDown(t *testing.T) {
+	testStores(t)
+	if liveHelper == nil {
+		t.Skip("MongoDB is unreachable")
+	}
+	defer liveHelper.RemoveCollection(MigrationCollection)
+	defer liveHelper.RemoveCollection("migration_test")
+	definition := IndexDefinition{Collection: "migration_test", Keys: bson.D{{Key: "email", Value: 1}}, Unique: true}
+	migrator, err := NewMigrator(liveHelper,
+		Migration{
+			Version: 1,
+			Up: func(ctx context.Context, db *mongo.Database) error {
+				return liveHelper.ReconcileIndexes(ctx, []IndexDefinition{definition}, false)
+			},
+			Down: func(ctx context.Context, db *mongo.Database) error {
+				_, err := db.Collection("migration_test").Indexes().DropOne(ctx, definition.IndexName())
+				return err
+			},
+		},
+		Migration{Version: 2, Up: noopMigration, Down: noopMigration},
+	)
+	if err != nil {
+		t.Fatal("NewMigrator", err)
+	}
+	ctx := context.Background()
+	for _, step := range []struct {
+		name    string
+		run     func(context.Context) error
+		version int
+	}{
+		{"Up", migrator.Up, 2},
+		{"Up again", migrator.Up, 2},
+		{"Down to 1", func(ctx context.Context) error { return migrator.Down(ctx, 1) }, 1},
+		{"Down to 0", func(ctx context.Context) error { return migrator.Down(ctx, 0) }, 0},
+	} {
+		err := step.run(ctx)
+		version, versionErr := migrator.Version(ctx)
+		if err != nil || versionErr != nil || version != step.version {
+			t.Fatal("Migrator", step.name, version, err, versionErr)
+		} else {
+			fmt.Println("Migrator-", step.name, "Pass")
+		}
+	}
+}
+
+// TestMigratorLockTTL checks that a lock lifetime shorter than a millisecond is rejected without connecting.
+func TestMigratorLockTTL(t *testing.T) {
+	migrator, _ := NewMigrator(NewMongoHelper("root", "local", "invalid.localhost", "1", "test"),
+		Migration{Version: 1, Up: noopMigration})
+	migrator.LockTTL = 0
+	if err := migrator.Up(context.Background()); err == nil || err.Error() != constants.InvalidLockTTL {
+		t.Fatal("Migrator LockTTL", err)
+	}
+	fmt.Println("Migrator LockTTL- Pass")
+}
+
+// TestMigratorLease checks that the lock is renewed while a migration outlives LockTTL,
+// so another instance waits for it, against a live database.
+// It is skipped if the database configured in constants is unreachable.
+func TestMigratorLease(t *testing.T) {
+	testStores(t)
+	if liveHelper == nil {
+		t.Skip("MongoDB is unreachable")
+	}
+	defer liveHelper.RemoveCollection(MigrationCollection)
+	defer liveHelper.RemoveCollection(MigrationLockCollection)
+	started := make(chan struct{})
+	slow, _ := NewMigrator(liveHelper, Migration{Version: 1, Up: func(ctx context.Context, db *mongo.Database) error {
+		close(started)
+		select {
+		case <-time.After(time.Second):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}})
+	slow.LockTTL = 200 * time.Millisecond
+	result := make(chan error, 1)
+	go func() { result <- slow.Up(context.Background()) }()
+	<-started
+	waiting, _ := NewMigrator(liveHelper, Migration{Version: 1, Up: noopMigration})
+	ctx, cancel := context.WithTimeout(context.Background(), 600*time.Millisecond)
+	defer cancel()
+	if err := waiting.Up(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatal("Migrator waiting for a renewed lock", err)
+	}
+	if err := <-result; err != nil {
+		t.Fatal("Migrator renewing its lock", err)
+	}
+	fmt.Println("Migrator lease- Pass")
+}
@@ -3,53 +3,66 @@ package database
 
 import (
 	"github.com/programmer-richa/utility/constants"
-	"github.com/programmer-richa/utility/functions"
 	"context"
+	"net"
 	"github.com/pkg/errors"
 	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// MongoHelper connects to a MongoDB deployment and manipulates the documents of one database.
 type MongoHelper struct {
-	dbUser     string
-	dbPassword string
-	dbHost     string
-	dbPort     string
-	database   string
+	config Config
+	// auditCollection receives the audit trail of the writes, if set by EnableAuditTrail.
+	auditCollection string
+	// monitor reports the connection and command metrics, if set by SetMetrics.
+	monitor *metricsMonitor
 }
 
+// NewMongoHelper returns a MongoHelper connecting to a single host with the provided credentials.
+// Use NewMongoHelperFromURI or NewMongoHelperWithConfig for replica sets, TLS and other options.
 func NewMongoHelper(dbUser string, dbPassword string, dbHost string, dbPort string, database string) *MongoHelper {
 	return &MongoHelper{
-		dbUser,
-		dbPassword,
-		dbHost,
-		dbPort,
-		database,
+		config: Config{
+			Hosts:    []string{net.JoinHostPort(dbHost, dbPort)},
+			Username: dbUser,
+			Password: dbPassword,
+			Database: database,
+		},
 	}
 }
 
 // GetSession creates a db session and returns its reference.
+// Errors never contain the configured password.
 func (m *MongoHelper) GetSession() (*mongo.Client, error) {
 	if m == nil {
 		return nil, errors.New(constants.NilMongoHelper)
 	}
+	return m.connect(context.TODO())
+}
+
+// connect creates a db session under ctx and checks the connection.
+// Errors never contain the configured password.
+func (m *MongoHelper) connect(ctx context.Context) (*mongo.Client, error) {
 	// Set client options
-	clientOptions := options.Client().ApplyURI("mongodb://" + m.dbUser + ":" + m.dbPassword + "@" + m.dbHost + ":" + m.dbPort)
-	// Connect to MongoDB
-	client, err := mongo.Connect(context.TODO(), clientOptions)
+	clientOptions, err := m.config.clientOptions()
 	if err != nil {
 		return nil, err
 	}
-	// Check the connection
-	err = client.Ping(context.TODO(), nil)
+	if m.monitor != nil {
+		m.monitor.apply(clientOptions)
+	}
+	// Connect to MongoDB
+	client, err := mongo.Connect(ctx, clientOptions)
 	if err != nil {
-		return nil, err
+		return nil, m.config.redactError(err)
 	}
-	// Return successful connection
-	return client, err
-
+	// Check the connection
+	if err := client.Ping(ctx, nil); err != nil {
+		client.Disconnect(context.TODO())
+		return nil, m.config.redactError(err)
+	}
+	return client, nil
 }
 
 // GetDatabase returns database reference
@@ -60,7 +73,7 @@ func (m *MongoHelper) GetDatabase(client *mongo.Client) (*mongo.Database, error)
 	if client == nil {
 		return nil, errors.New(constants.NilMongoClient)
 	}
-	db := client.Database(m.database)
+	db := client.Database(m.config.Database)
 	if db == nil {
 		return nil, errors.New(constants.DatabaseNotConnected)
 	}
@@ -79,160 +92,258 @@ func (m *MongoHelper) GetCollection(client *mongo.Client, collectionName string)
 	if err != nil {
 		return nil, err
 	}
-	collection := db.Collection(collectionName)
+	return db.Collection(collectionName), nil
+}
+
+// collection returns the named collection for an operation running under ctx.
+// When ctx belongs to a transaction started by WithTransaction the transaction's
+// client is reused, otherwise a new db session is created.
+// The returned release function must be called once the operation is complete.
+func (m *MongoHelper) collection(ctx context.Context, collectionName string) (*mongo.Collection, func(), error) {
+	if sessCtx := transactionFromContext(ctx); sessCtx != nil {
+		collection, err := m.GetCollection(sessCtx.Client(), collectionName)
+		if err != nil {
+			return nil, nil, err
+		}
+		return collection, func() {}, nil
+	}
+	// Create DB Session
+	client, err := m.GetSession()
+	if err != nil {
+		return nil, nil, err
+	}
+	// Close DB connection once the caller has executed the operation.
+	release := func() { client.Disconnect(context.TODO()) }
+	//Initialize DB Collection here
+	collection, err := m.GetCollection(client, collectionName)
 	if err != nil {
-		return nil, errors.New(constants.CollectionNotFound)
+		release()
+		return nil, nil, err
 	}
-	return collection, err
+	return collection, release, nil
 }
 
 // InsertDocument inserts an entry in the specified collection name using the provided db session
+// It returns an error matching ErrDuplicateKey if a unique index rejects the entry.
+// The audit fields of entry are stamped and its insert hooks are run, see Auditable and BeforeInserter.
 func (m *MongoHelper) InsertDocument(collectionName string, entry interface{}) (interface{}, error) {
+	return m.InsertDocumentContext(context.TODO(), collectionName, entry)
+}
+
+// InsertDocumentContext is InsertDocument executed under ctx.
+// It joins the transaction started by WithTransaction when given its context.
+func (m *MongoHelper) InsertDocumentContext(ctx context.Context, collectionName string, entry interface{}) (id interface{}, err error) {
+	defer classify(&err)
 	if m == nil {
 		return nil, errors.New(constants.NilMongoHelper)
 	}
-	// Create DB Session
-	client, err := m.GetSession()
-	if err != nil {
+	if err := beforeInsert(ctx, entry); err != nil {
 		return 0, err
 	}
-	// Close DB connection after this method is executed.
-	defer client.Disconnect(context.TODO())
-
-	// Initialize DB Collection here
-	collection, err := m.GetCollection(client, collectionName)
+	collection, release, err := m.collection(ctx, collectionName)
 	if err != nil {
-		return nil, errors.New(constants.CollectionNotFound)
+		return 0, err
 	}
+	defer release()
 	// Insert data into collection
-	res, err := collection.InsertOne(context.TODO(), entry)
+	res, err := collection.InsertOne(ctx, entry)
 	if err != nil {
 		return 0, err
 	}
+	if m.auditing(collectionName) {
+		document, err := toDocument(entry)
+		if err != nil {
+			return 0, err
+		}
+		document["_id"] = res.InsertedID
+		if err := m.recordAudit(ctx, collectionName, OperationInsert, res.InsertedID, nil, document); err != nil {
+			return 0, err
+		}
+	}
 	// Return ID of inserted document
-	return res.InsertedID, nil
+	return res.InsertedID, afterInsert(ctx, entry)
 }
 
-// UpdateDocument updates an entry in the specified collection name
+// UpdateDocument updates an entry in the specified collection name.
+// The fields of entry are set by their bson names, zero values included,
+// and the fields of embedded structs are set individually. Fields held by
+// nil pointers are left unchanged. See Update.SetFields for details.
+// The update audit fields of entry are stamped and its update hooks are run, see Auditable and BeforeUpdater.
 func (m *MongoHelper) UpdateDocument(collectionName string, id string, entry interface{}) error {
+	return m.UpdateDocumentContext(context.TODO(), collectionName, id, entry)
+}
+
+// UpdateDocumentContext is UpdateDocument executed under ctx.
+// It joins the transaction started by WithTransaction when given its context.
+func (m *MongoHelper) UpdateDocumentContext(ctx context.Context, collectionName string, id string, entry interface{}) error {
+	if err := beforeUpdate(ctx, entry); err != nil {
+		return err
+	}
+	if err := m.ApplyUpdate(ctx, collectionName, id, entryUpdate(ctx, entry)); err != nil {
+		return err
+	}
+	return afterUpdate(ctx, entry)
+}
+
+// ApplyUpdate applies update to the document with the provided id.
+// It joins the transaction started by WithTransaction when given its context.
+func (m *MongoHelper) ApplyUpdate(ctx context.Context, collectionName string, id string, update *Update) (err error) {
+	defer classify(&err)
 	if m == nil {
 		return errors.New(constants.NilMongoHelper)
 	}
-	// Create DB Session
-	client, err := m.GetSession()
-	if err != nil {
-		return err
+	if update == nil || update.IsEmpty() {
+		return errors.New(constants.InvalidUpdate)
 	}
-	// Close DB connection after this method is executed.
-	defer client.Disconnect(context.TODO())
-	//Initialize DB Collection here
-	collection, err := m.GetCollection(client, collectionName)
+	collection, release, err := m.collection(ctx, collectionName)
 	if err != nil {
-		return errors.New(constants.CollectionNotFound)
+		return err
 	}
-	docID, err := primitive.ObjectIDFromHex(id)
+	defer release()
+	docID, err := objectID(id)
 	if err != nil {
 		return err
 	}
-	// Covert struct data to bson map
-	doc := functions.ConvertToMap(entry, true, true)
-	document := bson.M{"$set": doc}
-	updateResult := collection.FindOneAndUpdate(context.TODO(), bson.M{"_id": docID}, document)
+	updateResult := collection.FindOneAndUpdate(ctx, bson.M{"_id": docID}, update.Document())
 	if updateResult.Err() != nil {
 		return updateResult.Err()
 	}
-	return nil
+	return m.auditUpdate(ctx, collectionName, docID, updateResult, update)
 }
 
 // IsExistingDocument tests the existence of a record with the provided conditions.
 // It returns true if the record is available in the collection.
 func (m *MongoHelper) IsExistingDocument(collectionName string, condition bson.M) (found bool, err error) {
+	return m.IsExistingDocumentContext(context.TODO(), collectionName, condition)
+}
+
+// IsExistingDocumentContext is IsExistingDocument executed under ctx.
+// It joins the transaction started by WithTransaction when given its context.
+func (m *MongoHelper) IsExistingDocumentContext(ctx context.Context, collectionName string, condition bson.M) (found bool, err error) {
+	defer classify(&err)
 	if m == nil {
 		return found, errors.New(constants.NilMongoClient)
 	}
-	// Create DB Session
-	client, err := m.GetSession()
+	collection, release, err := m.collection(ctx, collectionName)
 	if err != nil {
 		return found, err
 	}
-	// Close DB connection after this method is executed.
-	defer client.Disconnect(context.TODO())
-	//Initialize DB Collection here
-	collection, err := m.GetCollection(client, collectionName)
-	if err != nil {
-		return found, errors.New(constants.CollectionNotFound)
-	}
-	count, err := collection.CountDocuments(context.TODO(), condition)
+	defer release()
+	count, err := collection.CountDocuments(ctx, condition)
 	found = count > 0
 	return found, err
 }
 
 // GetaRecord returns a record that satisfies the provided conditions.
-// It returns nil if the record is unavailable in the collection.
+// It returns an error matching ErrNotFound if the record is unavailable in the collection.
 func (m *MongoHelper) GetaRecord(collectionName string, condition bson.M) (record interface{}, err error) {
+	return m.GetaRecordContext(context.TODO(), collectionName, condition)
+}
+
+// GetaRecordContext is GetaRecord executed under ctx.
+// It joins the transaction started by WithTransaction when given its context.
+func (m *MongoHelper) GetaRecordContext(ctx context.Context, collectionName string, condition bson.M) (record interface{}, err error) {
+	defer classify(&err)
 	if m == nil {
 		return nil, errors.New(constants.NilMongoHelper)
 	}
-	// Create DB Session
-	client, err := m.GetSession()
-	if err != nil {
-		return nil, err
-	}
-	// Close DB connection after this method is executed.
-	defer client.Disconnect(context.TODO())
-	//Initialize DB Collection here
-	collection, err := m.GetCollection(client, collectionName)
+	collection, release, err := m.collection(ctx, collectionName)
 	if err != nil {
-		return record, errors.New(constants.CollectionNotFound)
+		return record, err
 	}
-	err = collection.FindOne(context.TODO(), condition).Decode(&record)
+	defer release()
+	err = collection.FindOne(ctx, condition).Decode(&record)
 	return record, err
 }
 
-// FindDocument returns a record that satisfies the provided conditions.
-// It returns nil if the record is unavailable in the collection.
+// FindDocument returns the record with the provided id.
+// It returns an error matching ErrNotFound if the record is unavailable in the collection,
+// and one matching ErrInvalidID if id is not the hex form of an ObjectID.
 func (m *MongoHelper) FindDocument(collectionName string, id string) (record interface{}, err error) {
+	return m.FindDocumentContext(context.TODO(), collectionName, id)
+}
+
+// FindDocumentContext is FindDocument executed under ctx.
+// It joins the transaction started by WithTransaction when given its context.
+func (m *MongoHelper) FindDocumentContext(ctx context.Context, collectionName string, id string) (record interface{}, err error) {
+	defer classify(&err)
 	if m == nil {
 		return nil, errors.New(constants.NilMongoHelper)
 	}
-	// Create DB Session
-	client, err := m.GetSession()
+	collection, release, err := m.collection(ctx, collectionName)
+	if err != nil {
+		return record, err
+	}
+	defer release()
+	docID, err := objectID(id)
 	if err != nil {
 		return nil, err
 	}
-	// Close DB connection after this method is executed.
-	defer client.Disconnect(context.TODO())
-	//Initialize DB Collection here
-	collection, err := m.GetCollection(client, collectionName)
+	err = collection.FindOne(ctx, bson.M{"_id": docID}).Decode(&record)
+	return record, err
+}
+
+// DeleteDocument deletes the document with the provided id.
+// entry is the model being deleted, whose delete hooks are run, see BeforeDeleter.
+// It may be nil. It returns an error matching ErrNotFound if there is no document with the id.
+func (m *MongoHelper) DeleteDocument(collectionName string, id string, entry interface{}) error {
+	return m.DeleteDocumentContext(context.TODO(), collectionName, id, entry)
+}
+
+// DeleteDocumentContext is DeleteDocument executed under ctx.
+// It joins the transaction started by WithTransaction when given its context.
+func (m *MongoHelper) DeleteDocumentContext(ctx context.Context, collectionName string, id string, entry interface{}) (err error) {
+	defer classify(&err)
+	if m == nil {
+		return errors.New(constants.NilMongoHelper)
+	}
+	docID, err := objectID(id)
 	if err != nil {
-		return record, errors.New(constants.CollectionNotFound)
+		return err
+	}
+	if err := beforeDelete(ctx, entry); err != nil {
+		return err
 	}
-	docID, err := primitive.ObjectIDFromHex(id)
+	collection, release, err := m.collection(ctx, collectionName)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	err = collection.FindOne(context.TODO(), bson.M{"_id": docID}).Decode(&record)
-	return record, err
+	defer release()
+	result := collection.FindOneAndDelete(ctx, bson.M{"_id": docID})
+	if result.Err() != nil {
+		return result.Err()
+	}
+	deleted, err := m.auditDocument(collectionName, result)
+	if err != nil {
+		return err
+	}
+	if deleted != nil {
+		if err := m.recordAudit(ctx, collectionName, OperationDelete, docID, deleted, nil); err != nil {
+			return err
+		}
+	}
+	return afterDelete(ctx, entry)
 }
 
 // RemoveCollection deletes the collection from the database.
 // It returns nil if the collection successfully deleted.
 func (m *MongoHelper) RemoveCollection(collectionName string) (err error) {
+	return m.RemoveCollectionContext(context.TODO(), collectionName)
+}
+
+// RemoveCollectionContext is RemoveCollection executed under ctx.
+// Collections cannot be dropped inside a transaction, so ctx should not
+// belong to one.
+func (m *MongoHelper) RemoveCollectionContext(ctx context.Context, collectionName string) (err error) {
+	defer classify(&err)
 	if m == nil {
 		return errors.New(constants.NilMongoHelper)
 	}
-	// Create DB Session
-	client, err := m.GetSession()
+	collection, release, err := m.collection(ctx, collectionName)
 	if err != nil {
 		return err
 	}
-	// Close DB connection after this method is executed.
-	defer client.Disconnect(context.TODO())
-	//Initialize DB Collection here
-	collection, err := m.GetCollection(client, collectionName)
-	if err != nil {
-		return errors.New(constants.CollectionNotFound)
-	}
-	err = collection.Drop(context.TODO())
+	defer release()
+	err = collection.Drop(ctx)
 	return err
 }
@@ -2,9 +2,12 @@ package database
 
 import (
 	"github.com/programmer-richa/utility/constants"
+	"context"
+	"errors"
 	"fmt"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"testing"
 )
 
@@ -391,3 +394,76 @@ func TestMongoRemoveCollection(t *testing.T) {
 		})
 	}
 }
+
+// TestMongoWithTransaction runs several test cases to check the correctness of
+// the multi-document transaction functionality defined in database package.
+func TestMongoWithTransaction(t *testing.T) {
+
+	tests := []struct {
+		name           string
+		helper         *MongoHelper
+		collectionName string
+		fn             TransactionFunc
+		valid          bool
+	}{
+		{
+			"Nil value",
+			nil,
+			"test",
+			nil,
+			false,
+		}, {
+			"Nil transaction function",
+			NewMongoHelper(constants.DbUser, constants.DbPassword, constants.DbHost,
+				constants.DbPort, constants.Database),
+			"test",
+			nil,
+			false,
+		}, {
+			"Invalid  database credentials",
+			NewMongoHelper(constants.DbUser+"invalid", constants.DbPassword, constants.DbHost,
+				constants.DbPort, constants.Database),
+			"test",
+			func(txCtx context.Context) error { return nil },
+			false,
+		}, {
+			"Correct database credentials",
+			NewMongoHelper(constants.DbUser, constants.DbPassword, constants.DbHost,
+				constants.DbPort, constants.Database),
+			"test_transaction",
+			nil,
+			true,
+		},
+	}
+
+	for _, c := range tests {
+		t.Run(c.name, func(t *testing.T) {
+			fn := c.fn
+			if c.valid {
+				// Collections cannot be created inside transactions on older servers.
+				if _, err := c.helper.InsertDocument(c.collectionName, bson.M{"email": "setup@example.com"}); err != nil {
+					t.Fatal("Mongo DB WithTransaction Function ", c.name, err)
+				}
+				defer c.helper.RemoveCollection(c.collectionName)
+			}
+			if fn == nil && c.valid {
+				fn = func(txCtx context.Context) error {
+					_, err := c.helper.InsertDocumentContext(txCtx, c.collectionName,
+						NewUser("Richa", "programmer.richa@gmail.com", "123456",
+							false, false, primitive.NewObjectID()))
+					return err
+				}
+			}
+			err := c.helper.WithTransaction(context.Background(), fn)
+			var cmdErr mongo.CommandError
+			if c.valid && errors.As(err, &cmdErr) && cmdErr.Code == illegalOperationCode {
+				t.Skip("Transactions require a replica set")
+			}
+			if (err != nil) == c.valid {
+				t.Fatal("Mongo DB WithTransaction Function ", c.name, err)
+			} else {
+				fmt.Println("Mongo DB WithTransaction Function-", c.name, "Pass")
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package database
+
+import (
+	"github.com/programmer-richa/utility/constants"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// RateLimitCollection is the default collection of the counters of a RateLimiter.
+const RateLimitCollection = "_ratelimits"
+
+// RateWindow selects how a RateLimiter counts requests.
+type RateWindow int
+
+const (
+	// FixedWindow allows limit requests in each window, starting at multiples of the window duration.
+	// Up to twice the limit may pass around the boundary of two windows.
+	FixedWindow RateWindow = iota
+	// SlidingWindow allows limit requests in the window ending now, estimating the requests
+	// of the previous window made during it as evenly spread.
+	SlidingWindow
+)
+
+// RateLimit is the decision of a RateLimiter on a request.
+type RateLimit struct {
+	Allowed bool
+	// Remaining is the number of requests still allowed in the current window.
+	Remaining int
+	// ResetAt is the end of the current window.
+	ResetAt time.Time
+}
+
+// RateLimiter limits the requests made for a key, such as a user id or an IP address,
+// across every instance using the same collection.
+// Denied requests are not counted.
+type RateLimiter struct {
+	helper     *MongoHelper
+	collection string
+	mode       RateWindow
+	limit      int
+	window     time.Duration
+}
+
+// rateCounter is the stored count of the requests of a key in a window.
+type rateCounter struct {
+	ID        string    `bson:"_id"`
+	Count     int       `bson:"count"`
+	ExpiresAt time.Time `bson:"expiresAt"`
+}
+
+// NewRateLimiter returns a RateLimiter allowing limit requests per window for each key,
+// storing its counters in collectionName, or RateLimitCollection if it is empty.
+func NewRateLimiter(helper *MongoHelper, collectionName string, mode RateWindow, limit int, window time.Duration) (*RateLimiter, error) {
+	if helper == nil {
+		return nil, errors.New(constants.NilMongoHelper)
+	}
+	if limit <= 0 || window <= 0 {
+		return nil, errors.New(constants.InvalidRateLimit)
+	}
+	if collectionName == "" {
+		collectionName = RateLimitCollection
+	}
+	return &RateLimiter{helper: helper, collection: collectionName, mode: mode, limit: limit, window: window}, nil
+}
+
+// Indexes returns the TTL index removing the counters of past windows, to be passed to ReconcileIndexes.
+func (r *RateLimiter) Indexes() []IndexDefinition {
+	return []IndexDefinition{{Collection: r.collection, Keys: bson.D{{Key: "expiresAt", Value: 1}}, TTL: time.Second}}
+}
+
+// Allow counts a request for key if it is within the limit and reports the decision.
+func (r *RateLimiter) Allow(ctx context.Context, key string) (limit RateLimit, err error) {
+	defer classify(&err)
+	if r == nil {
+		return limit, errors.New(constants.NilMongoHelper)
+	}
+	collection, release, err := r.helper.collection(ctx, r.collection)
+	if err != nil {
+		return limit, err
+	}
+	defer release()
+	now := time.Now()
+	start := now.Truncate(r.window)
+	limit.ResetAt = start.Add(r.window)
+	capacity := r.limit
+	if r.mode == SlidingWindow {
+		var previous rateCounter
+		err := collection.FindOne(ctx, bson.M{"_id": r.counterID(key, start.Add(-r.window))}).Decode(&previous)
+		if err != nil && err != mongo.ErrNoDocuments {
+			return limit, err
+		}
+		capacity = slidingCapacity(r.limit, previous.Count, float64(now.Sub(start))/float64(r.window))
+	}
+	if capacity <= 0 {
+		return limit, nil
+	}
+	// The filter only matches a counter below capacity. If the counter is full the upsert
+	// fails with a duplicate key error, as the counter already exists.
+	// The first requests of a window may race to create the counter, so the loser retries once.
+	// Counters are kept for two windows, as a sliding window reads the previous one.
+	var counter rateCounter
+	for attempt := 0; attempt < 2; attempt++ {
+		err = collection.FindOneAndUpdate(ctx,
+			bson.M{"_id": r.counterID(key, start), "count": bson.M{"$lt": capacity}},
+			bson.M{
+				"$inc":         bson.M{"count": 1},
+				"$setOnInsert": bson.M{"expiresAt": start.Add(2 * r.window)},
+			},
+			options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)).Decode(&counter)
+		if !isDuplicateKeyError(err) {
+			break
+		}
+	}
+	if isDuplicateKeyError(err) {
+		return limit, nil
+	}
+	if err != nil {
+		return limit, err
+	}
+	limit.Allowed = true
+	limit.Remaining = capacity - counter.Count
+	return limit, nil
+}
+
+// counterID returns the id of the counter of key in the window starting at start.
+func (r *RateLimiter) counterID(key string, start time.Time) string {
+	return fmt.Sprintf("%s@%d", key, start.UnixNano())
+}
+
+// slidingCapacity returns how many requests the current window may count when
+// elapsed, a fraction of the window, has passed since it started and the previous
+// window counted previous requests, of which the ones made during the last
+// window duration are estimated as evenly spread.
+func slidingCapacity(limit int, previous int, elapsed float64) int {
+	return limit - int(math.Floor(float64(previous)*(1-elapsed)))
+}
@@ -0,0 +1,75 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// TestSlidingCapacity runs several test cases to check the estimate of the
+// requests allowed in the current window of a sliding window limiter.
+func TestSlidingCapacity(t *testing.T) {
+	tests := []struct {
+		name     string
+		previous int
+		elapsed  float64
+		expected int
+	}{
+		{"Empty previous window", 0, 0.5, 10},
+		{"Start of window", 10, 0, 0},
+		{"Half window", 10, 0.5, 5},
+		{"Partial request", 3, 0.5, 9},
+		{"Busy previous window", 30, 0.25, -12},
+	}
+
+	for _, c := range tests {
+		t.Run(c.name, func(t *testing.T) {
+			if capacity := slidingCapacity(10, c.previous, c.elapsed); capacity != c.expected {
+				t.Fatal("slidingCapacity", c.name, capacity)
+			} else {
+				fmt.Println("slidingCapacity-", c.name, "Pass")
+			}
+		})
+	}
+}
+
+// TestNewRateLimiter checks that a RateLimiter needs a positive limit and window.
+func TestNewRateLimiter(t *testing.T) {
+	helper := NewMongoHelper("root", "local", "localhost", "27017", "test")
+	if _, err := NewRateLimiter(helper, "", FixedWindow, 0, time.Minute); err == nil {
+		t.Fatal("NewRateLimiter accepted a zero limit")
+	}
+	if _, err := NewRateLimiter(helper, "", FixedWindow, 10, 0); err == nil {
+		t.Fatal("NewRateLimiter accepted a zero window")
+	}
+	limiter, err := NewRateLimiter(helper, "", SlidingWindow, 10, time.Minute)
+	if err != nil || limiter.collection != RateLimitCollection {
+		t.Fatal("NewRateLimiter", err)
+	}
+	fmt.Println("NewRateLimiter- Pass")
+}
+
+// TestRateLimiter checks that requests beyond the limit are denied against a live database.
+// It is skipped if the database configured in constants is unreachable.
+func TestRateLimiter(t *testing.T) {
+	testStores(t)
+	if liveHelper == nil {
+		t.Skip("MongoDB is unreachable")
+	}
+	defer liveHelper.RemoveCollection("ratelimit_test")
+	for _, mode := range []RateWindow{FixedWindow, SlidingWindow} {
+		limiter, err := NewRateLimiter(liveHelper, "ratelimit_test", mode, 3, time.Hour)
+		if err != nil {
+			t.Fatal("NewRateLimiter", err)
+		}
+		key := fmt.Sprint("login-", mode)
+		for i := 0; i < 5; i++ {
+			limit, err := limiter.Allow(context.Background(), key)
+			if err != nil || limit.Allowed != (i < 3) || (limit.Allowed && limit.Remaining != 2-i) {
+				t.Fatal("Allow", mode, i, limit, err)
+			}
+		}
+		fmt.Println("RateLimiter-", mode, "Pass")
+	}
+}
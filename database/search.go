@@ -0,0 +1,163 @@
+package database
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Fields added to the results of text and geospatial searches.
+// Models receive them by declaring a float64 field with the same bson name.
+const (
+	// ScoreField holds the relevance of a document to a text search, higher being better.
+	ScoreField = "score"
+	// DistanceField holds the distance in meters of a document to the point of a GeoNear search.
+	DistanceField = "distance"
+)
+
+// earthRadius is the radius of the Earth in meters used to convert distances to radians.
+const earthRadius = 6378100
+
+// Point is a GeoJSON point, e.g. the location of a store.
+type Point struct {
+	Type string `bson:"type" json:"type"`
+	// Coordinates are the longitude and latitude in that order.
+	Coordinates []float64 `bson:"coordinates" json:"coordinates"`
+}
+
+// NewPoint returns the point at longitude and latitude, in degrees.
+func NewPoint(longitude float64, latitude float64) Point {
+	return Point{Type: "Point", Coordinates: []float64{longitude, latitude}}
+}
+
+// Longitude returns the longitude of the point in degrees.
+func (p Point) Longitude() float64 {
+	if len(p.Coordinates) < 2 {
+		return 0
+	}
+	return p.Coordinates[0]
+}
+
+// Latitude returns the latitude of the point in degrees.
+func (p Point) Latitude() float64 {
+	if len(p.Coordinates) < 2 {
+		return 0
+	}
+	return p.Coordinates[1]
+}
+
+// Polygon is a GeoJSON polygon without holes, e.g. a delivery area.
+type Polygon struct {
+	Type string `bson:"type" json:"type"`
+	// Coordinates holds a single closed ring of longitude and latitude pairs.
+	Coordinates [][][]float64 `bson:"coordinates" json:"coordinates"`
+}
+
+// NewPolygon returns the polygon with the provided vertices, in counterclockwise order.
+// The ring is closed by repeating the first vertex if needed.
+func NewPolygon(vertices ...Point) Polygon {
+	ring := make([][]float64, 0, len(vertices)+1)
+	for _, vertex := range vertices {
+		ring = append(ring, []float64{vertex.Longitude(), vertex.Latitude()})
+	}
+	if len(ring) > 0 {
+		first, last := ring[0], ring[len(ring)-1]
+		if first[0] != last[0] || first[1] != last[1] {
+			ring = append(ring, first)
+		}
+	}
+	return Polygon{Type: "Polygon", Coordinates: [][][]float64{ring}}
+}
+
+// Near returns the condition on a field matching documents within maxDistance meters of point,
+// nearest first. A zero maxDistance does not limit the distance.
+// It needs a GeoIndex on the field, and cannot be combined with a sort.
+func Near(point Point, maxDistance float64) bson.M {
+	near := bson.M{"$geometry": point}
+	if maxDistance > 0 {
+		near["$maxDistance"] = maxDistance
+	}
+	return bson.M{"$near": near}
+}
+
+// Within returns the condition on a field matching documents located inside area.
+func Within(area Polygon) bson.M {
+	return bson.M{"$geoWithin": bson.M{"$geometry": area}}
+}
+
+// WithinRadius returns the condition on a field matching documents located within radius meters of center,
+// in no particular order.
+func WithinRadius(center Point, radius float64) bson.M {
+	return bson.M{"$geoWithin": bson.M{"$centerSphere": bson.A{
+		bson.A{center.Longitude(), center.Latitude()},
+		radius / earthRadius,
+	}}}
+}
+
+// TextSearch returns an aggregation of the documents of a collection matching the
+// text search search and filter, which may be nil, best first with their ScoreField set.
+// The search is run on the TextIndex of the collection; words are matched by stem,
+// "quoted phrases" are matched exactly and words prefixed with - are excluded.
+// More stages, such as Limit, may be added to the aggregation.
+func TextSearch(search string, filter bson.M) *Aggregation {
+	match := bson.M{"$text": bson.M{"$search": search}}
+	for key, value := range filter {
+		match[key] = value
+	}
+	score := bson.M{"$meta": "textScore"}
+	return NewAggregation().
+		Match(match).
+		AddFields(bson.M{ScoreField: score}).
+		Sort(bson.D{{Key: ScoreField, Value: score}})
+}
+
+// GeoNear returns an aggregation of the documents of a collection matching filter, which may be nil,
+// whose field is within maxDistance meters of point, nearest first with their DistanceField set.
+// A zero maxDistance does not limit the distance. It needs a GeoIndex on field.
+// More stages, such as Limit, may be added to the aggregation.
+func GeoNear(field string, point Point, maxDistance float64, filter bson.M) *Aggregation {
+	geoNear := bson.M{
+		"near":          point,
+		"key":           field,
+		"distanceField": DistanceField,
+		"spherical":     true,
+	}
+	if maxDistance > 0 {
+		geoNear["maxDistance"] = maxDistance
+	}
+	if len(filter) > 0 {
+		geoNear["query"] = filter
+	}
+	return NewAggregation().Stage("$geoNear", geoNear)
+}
+
+// SearchText decodes at most limit documents of the collection matching the text search
+// and filter into results, a pointer to a slice, best first. A zero limit returns every match.
+// See TextSearch for the search syntax.
+func (m *MongoHelper) SearchText(ctx context.Context, collectionName string, search string, filter bson.M, limit int64, results interface{}) error {
+	aggregation := TextSearch(search, filter)
+	if limit > 0 {
+		aggregation.Limit(limit)
+	}
+	return m.Aggregate(ctx, collectionName, aggregation, results)
+}
+
+// SearchNear decodes at most limit documents of the collection matching filter whose field
+// is within maxDistance meters of point into results, a pointer to a slice, nearest first.
+// Zero maxDistance and limit do not limit the distance and number of results.
+func (m *MongoHelper) SearchNear(ctx context.Context, collectionName string, field string, point Point, maxDistance float64, filter bson.M, limit int64, results interface{}) error {
+	aggregation := GeoNear(field, point, maxDistance, filter)
+	if limit > 0 {
+		aggregation.Limit(limit)
+	}
+	return m.Aggregate(ctx, collectionName, aggregation, results)
+}
+
+// SearchWithin decodes the documents of the collection matching filter whose field
+// is located inside area into results, a pointer to a slice.
+func (m *MongoHelper) SearchWithin(ctx context.Context, collectionName string, field string, area Polygon, filter bson.M, results interface{}) error {
+	match := bson.M{field: Within(area)}
+	for key, value := range filter {
+		match[key] = value
+	}
+	return m.Aggregate(ctx, collectionName, NewAggregation().Match(match), results)
+}
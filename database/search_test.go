@@ -0,0 +1,115 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// TestSearchPipeline runs several test cases to check the correctness of
+// the text and geospatial search builders defined in database package.
+func TestSearchPipeline(t *testing.T) {
+	here := NewPoint(77.2, 28.6)
+	tests := []struct {
+		name        string
+		aggregation *Aggregation
+		pipeline    mongo.Pipeline
+	}{
+		{
+			"Text search",
+			TextSearch("red shoes", bson.M{"active": true}).Limit(10),
+			mongo.Pipeline{
+				{{Key: "$match", Value: bson.M{"$text": bson.M{"$search": "red shoes"}, "active": true}}},
+				{{Key: "$addFields", Value: bson.M{"score": bson.M{"$meta": "textScore"}}}},
+				{{Key: "$sort", Value: bson.D{{Key: "score", Value: bson.M{"$meta": "textScore"}}}}},
+				{{Key: "$limit", Value: int64(10)}},
+			},
+		}, {
+			"Geo near",
+			GeoNear("location", here, 5000, bson.M{"open": true}),
+			mongo.Pipeline{
+				{{Key: "$geoNear", Value: bson.M{"near": here, "key": "location", "distanceField": "distance",
+					"spherical": true, "maxDistance": float64(5000), "query": bson.M{"open": true}}}},
+			},
+		}, {
+			"Unbounded geo near",
+			GeoNear("location", here, 0, nil),
+			mongo.Pipeline{
+				{{Key: "$geoNear", Value: bson.M{"near": here, "key": "location", "distanceField": "distance", "spherical": true}}},
+			},
+		},
+	}
+
+	for _, c := range tests {
+		t.Run(c.name, func(t *testing.T) {
+			if pipeline := c.aggregation.Pipeline(); !reflect.DeepEqual(pipeline, c.pipeline) {
+				t.Fatal("Search", c.name, pipeline)
+			} else {
+				fmt.Println("Search-", c.name, "Pass")
+			}
+		})
+	}
+}
+
+// TestNewPolygon checks that polygon rings are closed once.
+func TestNewPolygon(t *testing.T) {
+	closed := [][][]float64{{{0, 0}, {1, 0}, {1, 1}, {0, 0}}}
+	if polygon := NewPolygon(NewPoint(0, 0), NewPoint(1, 0), NewPoint(1, 1)); !reflect.DeepEqual(polygon.Coordinates, closed) {
+		t.Fatal("NewPolygon open ring", polygon.Coordinates)
+	}
+	if polygon := NewPolygon(NewPoint(0, 0), NewPoint(1, 0), NewPoint(1, 1), NewPoint(0, 0)); !reflect.DeepEqual(polygon.Coordinates, closed) {
+		t.Fatal("NewPolygon closed ring", polygon.Coordinates)
+	}
+	fmt.Println("NewPolygon- Pass")
+}
+
+// shop is a model located by a GeoJSON point.
+type shop struct {
+	Name     string  `bson:"name"`
+	Location Point   `bson:"location"`
+	Distance float64 `bson:"distance,omitempty"`
+	Score    float64 `bson:"score,omitempty"`
+}
+
+// TestMongoSearch checks text and geospatial searches against a live database.
+// It is skipped if the database configured in constants is unreachable.
+func TestMongoSearch(t *testing.T) {
+	testStores(t)
+	if liveHelper == nil {
+		t.Skip("MongoDB is unreachable")
+	}
+	ctx := context.Background()
+	defer liveHelper.RemoveCollection("search_test")
+	indexes := []IndexDefinition{TextIndex("search_test", "name"), GeoIndex("search_test", "location")}
+	if err := liveHelper.ReconcileIndexes(ctx, indexes, false); err != nil {
+		t.Fatal("ReconcileIndexes", err)
+	}
+	for _, s := range []shop{
+		{Name: "Connaught Place shoes", Location: NewPoint(77.2167, 28.6315)},
+		{Name: "Noida books", Location: NewPoint(77.3910, 28.5355)},
+	} {
+		if _, err := liveHelper.InsertDocument("search_test", s); err != nil {
+			t.Fatal("InsertDocument", err)
+		}
+	}
+	var found []shop
+	if err := liveHelper.SearchText(ctx, "search_test", "shoe", nil, 10, &found); err != nil ||
+		len(found) != 1 || found[0].Score <= 0 {
+		t.Fatal("SearchText", found, err)
+	}
+	found = nil
+	if err := liveHelper.SearchNear(ctx, "search_test", "location", NewPoint(77.2090, 28.6139), 0, nil, 0, &found); err != nil ||
+		len(found) != 2 || found[0].Name != "Connaught Place shoes" || found[0].Distance <= 0 || found[1].Distance <= found[0].Distance {
+		t.Fatal("SearchNear", found, err)
+	}
+	found = nil
+	area := NewPolygon(NewPoint(77.3, 28.5), NewPoint(77.5, 28.5), NewPoint(77.5, 28.6), NewPoint(77.3, 28.6))
+	if err := liveHelper.SearchWithin(ctx, "search_test", "location", area, nil, &found); err != nil ||
+		len(found) != 1 || found[0].Name != "Noida books" {
+		t.Fatal("SearchWithin", found, err)
+	}
+	fmt.Println("Search- Pass")
+}
@@ -0,0 +1,37 @@
+package database
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DocumentStore lists the document operations of MongoHelper.
+// Code depending on DocumentStore rather than *MongoHelper can be unit tested
+// with the in-memory implementation returned by NewMemoryStore.
+type DocumentStore interface {
+	InsertDocument(collectionName string, entry interface{}) (interface{}, error)
+	InsertDocumentContext(ctx context.Context, collectionName string, entry interface{}) (interface{}, error)
+	UpdateDocument(collectionName string, id string, entry interface{}) error
+	UpdateDocumentContext(ctx context.Context, collectionName string, id string, entry interface{}) error
+	ApplyUpdate(ctx context.Context, collectionName string, id string, update *Update) error
+	UpdateDocumentVersioned(ctx context.Context, collectionName string, id string, version int64, entry interface{}) (int64, error)
+	ApplyVersionedUpdate(ctx context.Context, collectionName string, id string, version int64, update *Update) (int64, error)
+	IsExistingDocument(collectionName string, condition bson.M) (bool, error)
+	IsExistingDocumentContext(ctx context.Context, collectionName string, condition bson.M) (bool, error)
+	GetaRecord(collectionName string, condition bson.M) (interface{}, error)
+	GetaRecordContext(ctx context.Context, collectionName string, condition bson.M) (interface{}, error)
+	FindDocument(collectionName string, id string) (interface{}, error)
+	FindDocumentContext(ctx context.Context, collectionName string, id string) (interface{}, error)
+	DeleteDocument(collectionName string, id string, entry interface{}) error
+	DeleteDocumentContext(ctx context.Context, collectionName string, id string, entry interface{}) error
+	RemoveCollection(collectionName string) error
+	RemoveCollectionContext(ctx context.Context, collectionName string) error
+	WithTransaction(ctx context.Context, fn TransactionFunc, opts ...*options.TransactionOptions) error
+}
+
+// Both implementations must satisfy DocumentStore.
+var (
+	_ DocumentStore = (*MongoHelper)(nil)
+	_ DocumentStore = (*MemoryStore)(nil)
+)
@@ -0,0 +1,198 @@
+package database
+
+import (
+	"github.com/programmer-richa/utility/constants"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// illegalOperationCode is returned by standalone servers for transactions.
+const illegalOperationCode = 20
+
+var (
+	liveHelperOnce sync.Once
+	liveHelper     *MongoHelper
+)
+
+// testStores returns the DocumentStore implementations exercised by the store tests.
+// The MongoHelper is only included if the database configured in constants is reachable.
+func testStores(t *testing.T) map[string]DocumentStore {
+	stores := map[string]DocumentStore{"MemoryStore": NewMemoryStore()}
+	liveHelperOnce.Do(func() {
+		helper, err := NewMongoHelperWithConfig(Config{
+			URI:      "mongodb://" + net.JoinHostPort(constants.DbHost, constants.DbPort) + "/?serverSelectionTimeoutMS=2000",
+			Username: constants.DbUser,
+			Password: constants.DbPassword,
+			Database: constants.Database,
+		})
+		if err != nil {
+			t.Fatal("NewMongoHelperWithConfig", err)
+		}
+		if client, err := helper.GetSession(); err == nil {
+			client.Disconnect(context.TODO())
+			liveHelper = helper
+		}
+	})
+	if liveHelper != nil {
+		stores["MongoHelper"] = liveHelper
+	}
+	return stores
+}
+
+// TestStoreInsertDocument runs several test cases to check the correctness of
+// the insert and find functionality of every DocumentStore implementation.
+func TestStoreInsertDocument(t *testing.T) {
+	for storeName, store := range testStores(t) {
+		t.Run(storeName, func(t *testing.T) {
+			defer store.RemoveCollection("store_insert")
+			user := NewUser("Richa", "programmer.richa@gmail.com", "123456",
+				false, false, primitive.NewObjectID())
+			id, err := store.InsertDocument("store_insert", user)
+			if err != nil || id != user.Id {
+				t.Fatal("InsertDocument", storeName, id, err)
+			}
+			if _, err := store.InsertDocument("store_insert", user); err == nil {
+				t.Fatal("InsertDocument accepted a duplicate _id", storeName)
+			}
+			generated, err := store.InsertDocument("store_insert", bson.M{"email": "generated@example.com"})
+			if _, ok := generated.(primitive.ObjectID); err != nil || !ok {
+				t.Fatal("InsertDocument did not generate an _id", storeName, generated, err)
+			}
+			data, err := store.FindDocument("store_insert", user.Id.Hex())
+			found := User{}
+			bsonBytes, _ := bson.Marshal(data)
+			bson.Unmarshal(bsonBytes, &found)
+			if err != nil || found.Email != user.Email {
+				t.Fatal("FindDocument", storeName, found, err)
+			}
+			if _, err := store.FindDocument("store_insert", primitive.NewObjectID().Hex()); !errors.Is(err, ErrNotFound) {
+				t.Fatal("FindDocument of a missing document", storeName, err)
+			}
+			fmt.Println("Store InsertDocument-", storeName, "Pass")
+		})
+	}
+}
+
+// TestStoreFilters runs several test cases to check the correctness of
+// the supported filter subset of every DocumentStore implementation.
+func TestStoreFilters(t *testing.T) {
+	tests := []struct {
+		name      string
+		condition bson.M
+		found     bool
+	}{
+		{"Equality", bson.M{"name": "Richa"}, true},
+		{"Equality without match", bson.M{"name": "Sahil"}, false},
+		{"Equality on embedded field", bson.M{"address.city": "Delhi"}, true},
+		{"Equality with array element", bson.M{"tags": "admin"}, true},
+		{"In", bson.M{"name": bson.M{"$in": []string{"Sahil", "Richa"}}}, true},
+		{"In without match", bson.M{"name": bson.M{"$in": []string{"Sahil"}}}, false},
+		{"Greater than", bson.M{"age": bson.M{"$gt": 29}}, true},
+		{"Greater than without match", bson.M{"age": bson.M{"$gt": 30}}, false},
+		{"Less than", bson.M{"age": bson.M{"$lt": 30.5}}, true},
+		{"Range", bson.M{"age": bson.M{"$gte": 30, "$lte": 30}}, true},
+		{"Mismatched types", bson.M{"age": bson.M{"$gt": "29"}}, false},
+		{"Several conditions", bson.M{"name": "Richa", "age": bson.M{"$lt": 20}}, false},
+	}
+
+	for storeName, store := range testStores(t) {
+		t.Run(storeName, func(t *testing.T) {
+			defer store.RemoveCollection("store_filters")
+			_, err := store.InsertDocument("store_filters", bson.M{
+				"name":    "Richa",
+				"age":     30,
+				"tags":    []string{"admin", "author"},
+				"address": bson.M{"city": "Delhi"},
+			})
+			if err != nil {
+				t.Fatal("InsertDocument", storeName, err)
+			}
+			for _, c := range tests {
+				found, err := store.IsExistingDocument("store_filters", c.condition)
+				if err != nil || found != c.found {
+					t.Fatal("IsExistingDocument", storeName, c.name, found, err)
+				} else {
+					fmt.Println("Store filter-", storeName, c.name, "Pass")
+				}
+			}
+		})
+	}
+}
+
+// TestStoreUpdateDocument runs several test cases to check the correctness of
+// the update functionality of every DocumentStore implementation.
+func TestStoreUpdateDocument(t *testing.T) {
+	for storeName, store := range testStores(t) {
+		t.Run(storeName, func(t *testing.T) {
+			defer store.RemoveCollection("store_update")
+			user := NewUser("Richa", "programmer.richa@gmail.com", "123456",
+				false, false, primitive.NewObjectID())
+			if _, err := store.InsertDocument("store_update", user); err != nil {
+				t.Fatal("InsertDocument", storeName, err)
+			}
+			user.Password = "newPass"
+			err := store.UpdateDocument("store_update", user.Id.Hex(), user)
+			if err != nil {
+				t.Fatal("UpdateDocument", storeName, err)
+			}
+			found, err := store.IsExistingDocument("store_update", bson.M{"_id": user.Id, "password": "newPass"})
+			if err != nil || !found {
+				t.Fatal("UpdateDocument did not update the document", storeName, err)
+			}
+			err = store.UpdateDocument("store_update", primitive.NewObjectID().Hex(), user)
+			if !errors.Is(err, ErrNotFound) {
+				t.Fatal("UpdateDocument of a missing document", storeName, err)
+			}
+			fmt.Println("Store UpdateDocument-", storeName, "Pass")
+		})
+	}
+}
+
+// TestStoreWithTransaction runs several test cases to check the correctness of
+// the transaction functionality of every DocumentStore implementation.
+func TestStoreWithTransaction(t *testing.T) {
+	errRollback := errors.New("rollback")
+	tests := []struct {
+		name  string
+		email string
+		err   error
+	}{
+		{"Committed transaction", "committed@example.com", nil},
+		{"Aborted transaction", "aborted@example.com", errRollback},
+	}
+
+	for storeName, store := range testStores(t) {
+		t.Run(storeName, func(t *testing.T) {
+			defer store.RemoveCollection("store_transaction")
+			// Collections cannot be created inside transactions on older servers.
+			if _, err := store.InsertDocument("store_transaction", bson.M{"email": "setup@example.com"}); err != nil {
+				t.Fatal("InsertDocument", storeName, err)
+			}
+			for _, c := range tests {
+				err := store.WithTransaction(context.Background(), func(txCtx context.Context) error {
+					if _, err := store.InsertDocumentContext(txCtx, "store_transaction", bson.M{"email": c.email}); err != nil {
+						return err
+					}
+					return c.err
+				})
+				var cmdErr mongo.CommandError
+				if errors.As(err, &cmdErr) && cmdErr.Code == illegalOperationCode {
+					t.Skip("Transactions require a replica set", storeName)
+				}
+				found, findErr := store.IsExistingDocument("store_transaction", bson.M{"email": c.email})
+				if err != c.err || findErr != nil || found != (c.err == nil) {
+					t.Fatal("WithTransaction", storeName, c.name, err, findErr)
+				} else {
+					fmt.Println("Store WithTransaction-", storeName, c.name, "Pass")
+				}
+			}
+		})
+	}
+}
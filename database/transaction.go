@@ -0,0 +1,118 @@
+package database
+
+import (
+	"github.com/programmer-richa/utility/constants"
+	"context"
+	"errors"
+	"time"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Error labels attached by the server to errors that are safe to retry.
+const (
+	transientTransactionError      = "TransientTransactionError"
+	unknownTransactionCommitResult = "UnknownTransactionCommitResult"
+)
+
+// transactionTimeout bounds the time spent retrying a transaction.
+var transactionTimeout = 120 * time.Second
+
+// transactionKey is the context key under which the active transaction is stored.
+type transactionKey struct{}
+
+// TransactionFunc is the unit of work executed by WithTransaction.
+// txCtx must be passed to the helper methods that should be part of the transaction.
+type TransactionFunc func(txCtx context.Context) error
+
+// transactionFromContext returns the transaction carried by ctx, if any.
+func transactionFromContext(ctx context.Context) mongo.SessionContext {
+	if ctx == nil {
+		return nil
+	}
+	sessCtx, _ := ctx.Value(transactionKey{}).(mongo.SessionContext)
+	return sessCtx
+}
+
+// hasErrorLabel tests if err, or an error it wraps, carries the server error label.
+func hasErrorLabel(err error, label string) bool {
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) {
+		return cmdErr.HasErrorLabel(label)
+	}
+	return false
+}
+
+// WithTransaction runs fn inside a multi-document transaction.
+// The transaction is committed if fn returns nil and aborted otherwise.
+// Transient transaction errors and unknown commit results are retried,
+// so fn may be called more than once and must be idempotent.
+// If ctx already belongs to a transaction, fn joins it instead of starting a new one.
+func (m *MongoHelper) WithTransaction(ctx context.Context, fn TransactionFunc, opts ...*options.TransactionOptions) error {
+	if m == nil {
+		return errors.New(constants.NilMongoHelper)
+	}
+	if fn == nil {
+		return errors.New(constants.NilTransactionFunc)
+	}
+	if transactionFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+	// Create DB Session
+	client, err := m.GetSession()
+	if err != nil {
+		return err
+	}
+	// Close DB connection after this method is executed.
+	defer client.Disconnect(context.TODO())
+	session, err := client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+	return mongo.WithSession(ctx, session, func(sessCtx mongo.SessionContext) error {
+		return runTransaction(sessCtx, fn, opts...)
+	})
+}
+
+// runTransaction executes fn in a transaction on the session of sessCtx,
+// retrying on errors labelled as retryable until transactionTimeout elapses.
+func runTransaction(sessCtx mongo.SessionContext, fn TransactionFunc, opts ...*options.TransactionOptions) error {
+	deadline := time.Now().Add(transactionTimeout)
+	txCtx := context.WithValue(sessCtx, transactionKey{}, sessCtx)
+	for {
+		if err := sessCtx.StartTransaction(opts...); err != nil {
+			return err
+		}
+		if err := fn(txCtx); err != nil {
+			// ignore abort errors, the callback error is more relevant
+			_ = sessCtx.AbortTransaction(sessCtx)
+			if hasErrorLabel(err, transientTransactionError) && time.Now().Before(deadline) {
+				continue
+			}
+			return err
+		}
+		err := commitTransaction(sessCtx, deadline)
+		if hasErrorLabel(err, transientTransactionError) && time.Now().Before(deadline) {
+			continue
+		}
+		return err
+	}
+}
+
+// commitTransaction commits the active transaction of sessCtx,
+// retrying the commit while its result is unknown.
+func commitTransaction(sessCtx mongo.SessionContext, deadline time.Time) error {
+	for {
+		err := sessCtx.CommitTransaction(sessCtx)
+		if err == nil || !time.Now().Before(deadline) {
+			return err
+		}
+		var cmdErr mongo.CommandError
+		if errors.As(err, &cmdErr) && cmdErr.HasErrorLabel(unknownTransactionCommitResult) &&
+			!cmdErr.IsMaxTimeMSExpiredError() {
+			continue
+		}
+		return err
+	}
+}
@@ -0,0 +1,130 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// fakeSession is a session whose commits fail with the queued errors, to exercise the retries of transactions.
+type fakeSession struct {
+	mongo.SessionContext
+	ctx          context.Context
+	commitErrors []error
+	starts       int
+	aborts       int
+	commits      int
+}
+
+func (s *fakeSession) Deadline() (time.Time, bool)       { return s.ctx.Deadline() }
+func (s *fakeSession) Done() <-chan struct{}             { return s.ctx.Done() }
+func (s *fakeSession) Err() error                        { return s.ctx.Err() }
+func (s *fakeSession) Value(key interface{}) interface{} { return s.ctx.Value(key) }
+
+func (s *fakeSession) StartTransaction(opts ...*options.TransactionOptions) error {
+	s.starts++
+	return nil
+}
+
+func (s *fakeSession) AbortTransaction(ctx context.Context) error {
+	s.aborts++
+	return nil
+}
+
+func (s *fakeSession) CommitTransaction(ctx context.Context) error {
+	s.commits++
+	if len(s.commitErrors) == 0 {
+		return nil
+	}
+	err := s.commitErrors[0]
+	s.commitErrors = s.commitErrors[1:]
+	return err
+}
+
+// labelled returns a server error carrying label.
+func labelled(label string) error {
+	return mongo.CommandError{Code: 112, Name: "WriteConflict", Labels: []string{label}}
+}
+
+// TestRunTransaction runs several test cases to check the correctness of
+// the retries of transactions defined in database package.
+func TestRunTransaction(t *testing.T) {
+	errFailed := errors.New("insufficient balance")
+	tests := []struct {
+		name         string
+		fnErrors     []error
+		commitErrors []error
+		err          error
+		calls        int
+		starts       int
+		commits      int
+	}{
+		{"Committed", nil, nil, nil, 1, 1, 1},
+		{"Transient error of fn", []error{labelled(transientTransactionError)}, nil, nil, 2, 2, 1},
+		{"Transient commit error", nil, []error{labelled(transientTransactionError)}, nil, 2, 2, 2},
+		{"Unknown commit result", nil, []error{labelled(unknownTransactionCommitResult),
+			labelled(unknownTransactionCommitResult)}, nil, 1, 1, 3},
+		{"Failed fn", []error{errFailed}, nil, errFailed, 1, 1, 0},
+	}
+
+	for _, c := range tests {
+		t.Run(c.name, func(t *testing.T) {
+			session := &fakeSession{ctx: context.Background(), commitErrors: c.commitErrors}
+			fnErrors := c.fnErrors
+			calls := 0
+			err := runTransaction(session, func(txCtx context.Context) error {
+				calls++
+				if len(fnErrors) == 0 {
+					return nil
+				}
+				err := fnErrors[0]
+				fnErrors = fnErrors[1:]
+				return err
+			})
+			if err != c.err || calls != c.calls || session.starts != c.starts || session.commits != c.commits {
+				t.Fatal("runTransaction", c.name, err, calls, session.starts, session.commits)
+			} else {
+				fmt.Println("runTransaction-", c.name, "Pass")
+			}
+		})
+	}
+}
+
+// TestRunTransactionTimeout checks that retries stop once the transaction timeout elapsed.
+func TestRunTransactionTimeout(t *testing.T) {
+	defer func(timeout time.Duration) { transactionTimeout = timeout }(transactionTimeout)
+	transactionTimeout = 20 * time.Millisecond
+	session := &fakeSession{ctx: context.Background()}
+	err := runTransaction(session, func(txCtx context.Context) error {
+		time.Sleep(5 * time.Millisecond)
+		return labelled(transientTransactionError)
+	})
+	if !hasErrorLabel(err, transientTransactionError) || session.starts < 2 || session.aborts != session.starts {
+		t.Fatal("runTransaction timeout", err, session.starts, session.aborts)
+	}
+	fmt.Println("runTransaction- Timeout Pass")
+}
+
+// TestNestedTransaction checks that WithTransaction joins the transaction of its context
+// instead of starting a new one.
+func TestNestedTransaction(t *testing.T) {
+	// The helper cannot connect, so a nested call starting its own session would fail.
+	helper := NewMongoHelper("root", "local", "invalid.localhost", "1", "test")
+	session := &fakeSession{ctx: context.Background()}
+	var outer, inner mongo.SessionContext
+	err := runTransaction(session, func(txCtx context.Context) error {
+		outer = transactionFromContext(txCtx)
+		return helper.WithTransaction(txCtx, func(nestedCtx context.Context) error {
+			inner = transactionFromContext(nestedCtx)
+			return nil
+		})
+	})
+	if err != nil || outer != session || inner != session || session.starts != 1 || session.commits != 1 {
+		t.Fatal("Nested WithTransaction", err, outer, inner, session.starts, session.commits)
+	}
+	fmt.Println("Nested WithTransaction- Pass")
+}
@@ -0,0 +1,235 @@
+package database
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"time"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsoncodec"
+)
+
+// primitivePackage holds BSON types, like ObjectID and Decimal128, that are stored as single values.
+const primitivePackage = "go.mongodb.org/mongo-driver/bson/primitive"
+
+// Types stored as single values even though they are structs.
+var (
+	timeType           = reflect.TypeOf(time.Time{})
+	marshalerType      = reflect.TypeOf((*bson.Marshaler)(nil)).Elem()
+	valueMarshalerType = reflect.TypeOf((*bsoncodec.ValueMarshaler)(nil)).Elem()
+)
+
+// Update describes the changes ApplyUpdate applies to a document.
+// Fields are addressed by their bson names, with dots separating the fields of embedded documents.
+type Update struct {
+	set   bson.M
+	unset bson.M
+	inc   bson.M
+	push  bson.M
+}
+
+// NewUpdate returns an empty Update.
+func NewUpdate() *Update {
+	return &Update{set: bson.M{}, unset: bson.M{}, inc: bson.M{}, push: bson.M{}}
+}
+
+// Set sets the field at path to value, zero values included.
+func (u *Update) Set(path string, value interface{}) *Update {
+	u.set[path] = value
+	return u
+}
+
+// Unset removes the fields at paths from the document.
+func (u *Update) Unset(paths ...string) *Update {
+	for _, path := range paths {
+		u.unset[path] = ""
+	}
+	return u
+}
+
+// Inc increments the numeric field at path by amount, which may be negative.
+func (u *Update) Inc(path string, amount interface{}) *Update {
+	u.inc[path] = amount
+	return u
+}
+
+// Push appends values to the array field at path.
+func (u *Update) Push(path string, values ...interface{}) *Update {
+	if len(values) == 1 {
+		u.push[path] = values[0]
+	} else {
+		u.push[path] = bson.M{"$each": values}
+	}
+	return u
+}
+
+// SetFields sets the fields of entry, a struct or a map, keyed by their bson names.
+// Embedded structs are flattened to dotted paths, so their other fields are kept.
+// The _id field and fields tagged omitempty holding zero values are skipped.
+//
+// Without fields, every other field is set, zero values included,
+// except nil pointers which mark the fields left unchanged.
+// With fields, only the named paths, and the fields embedded under them, are set.
+func (u *Update) SetFields(entry interface{}, fields ...string) *Update {
+	flattened := bson.M{}
+	flatten(flattened, "", reflect.ValueOf(entry), len(fields) > 0)
+	for path, value := range flattened {
+		if path == "_id" || (len(fields) > 0 && !inMask(path, fields)) {
+			continue
+		}
+		u.set[path] = value
+	}
+	return u
+}
+
+// IsEmpty tests if the update changes nothing.
+func (u *Update) IsEmpty() bool {
+	return len(u.set) == 0 && len(u.unset) == 0 && len(u.inc) == 0 && len(u.push) == 0
+}
+
+// Document returns the update document passed to MongoDB.
+func (u *Update) Document() bson.M {
+	document := bson.M{}
+	for operator, fields := range map[string]bson.M{"$set": u.set, "$unset": u.unset, "$inc": u.inc, "$push": u.push} {
+		if len(fields) > 0 {
+			document[operator] = fields
+		}
+	}
+	return document
+}
+
+// clone returns a copy of the update.
+func (u *Update) clone() *Update {
+	cloned := NewUpdate()
+	for _, op := range []struct{ from, to bson.M }{
+		{u.set, cloned.set}, {u.unset, cloned.unset}, {u.inc, cloned.inc}, {u.push, cloned.push},
+	} {
+		for path, value := range op.from {
+			op.to[path] = value
+		}
+	}
+	return cloned
+}
+
+// versioned returns a copy of the update that increments the version field
+// instead of changing it directly.
+func (u *Update) versioned() *Update {
+	versioned := u.clone()
+	for _, fields := range []bson.M{versioned.set, versioned.unset, versioned.inc, versioned.push} {
+		delete(fields, VersionField)
+	}
+	return versioned.Inc(VersionField, 1)
+}
+
+// Stamp sets the update fields of AuditFields, updatedAt to the current time and updatedBy
+// to the actor of ctx, if any, for models embedding AuditFields that are updated by ApplyUpdate.
+// Audit fields already changed by the update are left to it.
+func (u *Update) Stamp(ctx context.Context) *Update {
+	if !u.changes(updatedAtField) {
+		u.Set(updatedAtField, auditTime())
+	}
+	if actor := ActorFromContext(ctx); actor != "" && !u.changes(updatedByField) {
+		u.Set(updatedByField, actor)
+	}
+	return u
+}
+
+// changes tests if the update sets or removes the field at path.
+func (u *Update) changes(path string) bool {
+	_, set := u.set[path]
+	_, unset := u.unset[path]
+	return set || unset
+}
+
+// inMask tests if path is one of fields or is embedded in one of them.
+func inMask(path string, fields []string) bool {
+	for _, field := range fields {
+		if path == field || strings.HasPrefix(path, field+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// flatten adds the fields of v to set, keyed by their dotted bson paths under prefix.
+// keepNil stores nil pointers as null values instead of skipping them.
+func flatten(set bson.M, prefix string, v reflect.Value, keepNil bool) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			if keepNil && prefix != "" {
+				set[prefix] = nil
+			}
+			return
+		}
+		v = v.Elem()
+	}
+	switch {
+	case v.Kind() == reflect.Struct && !isSingleValue(v.Type()):
+		flattenStruct(set, prefix, v, keepNil)
+	case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String && prefix == "":
+		for _, key := range v.MapKeys() {
+			set[key.String()] = v.MapIndex(key).Interface()
+		}
+	case prefix != "":
+		set[prefix] = v.Interface()
+	}
+}
+
+// flattenStruct adds the exported fields of the struct v to set, following the rules of the bson encoder.
+func flattenStruct(set bson.M, prefix string, v reflect.Value, keepNil bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name, omitEmpty, inline, skip := bsonTag(field)
+		if skip {
+			continue
+		}
+		value := v.Field(i)
+		if omitEmpty && value.IsZero() {
+			continue
+		}
+		path := name
+		if inline {
+			path = prefix
+		} else if prefix != "" {
+			path = prefix + "." + name
+		}
+		flatten(set, path, value, keepNil)
+	}
+}
+
+// bsonTag returns the key of a struct field and its options, as the bson encoder does.
+// Fields without a key are stored under their lower-cased name.
+func bsonTag(field reflect.StructField) (name string, omitEmpty bool, inline bool, skip bool) {
+	tag, ok := field.Tag.Lookup("bson")
+	if !ok && !strings.Contains(string(field.Tag), ":") && len(field.Tag) > 0 {
+		tag = string(field.Tag)
+	}
+	if tag == "-" {
+		return "", false, false, true
+	}
+	parts := strings.Split(tag, ",")
+	name = parts[0]
+	for _, option := range parts[1:] {
+		switch option {
+		case "omitempty":
+			omitEmpty = true
+		case "inline":
+			inline = true
+		}
+	}
+	if name == "" {
+		name = strings.ToLower(field.Name)
+	}
+	return name, omitEmpty, inline, false
+}
+
+// isSingleValue tests if values of the struct type t are stored as a single BSON value.
+func isSingleValue(t reflect.Type) bool {
+	return t == timeType || t.PkgPath() == primitivePackage ||
+		t.Implements(marshalerType) || reflect.PtrTo(t).Implements(marshalerType) ||
+		t.Implements(valueMarshalerType) || reflect.PtrTo(t).Implements(valueMarshalerType)
+}
@@ -0,0 +1,139 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Address is embedded in Profile to test flattening of nested structs.
+type Address struct {
+	City    string `bson:"city"`
+	ZipCode string `bson:"zip_code,omitempty"`
+}
+
+// Profile is a model with nested, optional and tagged fields.
+type Profile struct {
+	Id        primitive.ObjectID `bson:"_id"`
+	Name      string             `bson:"name"`
+	Visits    int                `bson:"visits"`
+	Active    bool               `bson:"active"`
+	Nickname  *string            `bson:"nickname"`
+	Address   Address            `bson:"address"`
+	Joined    time.Time          `bson:"joined"`
+	Ignored   string             `bson:"-"`
+	Untagged  string
+	unexposed string
+}
+
+// TestUpdateSetFields runs several test cases to check the correctness of
+// the update document construction defined in database package.
+func TestUpdateSetFields(t *testing.T) {
+	nickname := ""
+	joined := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	profile := Profile{
+		Id:       primitive.NewObjectID(),
+		Name:     "Richa",
+		Address:  Address{City: "Delhi"},
+		Joined:   joined,
+		Ignored:  "ignored",
+		Untagged: "untagged",
+	}
+	withNickname := profile
+	withNickname.Nickname = &nickname
+
+	tests := []struct {
+		name     string
+		update   *Update
+		document bson.M
+	}{
+		{
+			"Zero values, bson names and nested paths",
+			NewUpdate().SetFields(profile),
+			bson.M{"$set": bson.M{
+				"name":         "Richa",
+				"visits":       0,
+				"active":       false,
+				"address.city": "Delhi",
+				"joined":       joined,
+				"untagged":     "untagged",
+			}},
+		},
+		{
+			"Pointer to zero value is set",
+			NewUpdate().SetFields(withNickname, "nickname"),
+			bson.M{"$set": bson.M{"nickname": ""}},
+		},
+		{
+			"Field mask",
+			NewUpdate().SetFields(profile, "visits", "address"),
+			bson.M{"$set": bson.M{"visits": 0, "address.city": "Delhi"}},
+		},
+		{
+			"Map entry",
+			NewUpdate().SetFields(bson.M{"name": "Richa", "visits": 0}),
+			bson.M{"$set": bson.M{"name": "Richa", "visits": 0}},
+		},
+		{
+			"Unset, increment and push",
+			NewUpdate().Unset("nickname").Inc("visits", 1).Push("tags", "a", "b"),
+			bson.M{
+				"$unset": bson.M{"nickname": ""},
+				"$inc":   bson.M{"visits": 1},
+				"$push":  bson.M{"tags": bson.M{"$each": []interface{}{"a", "b"}}},
+			},
+		},
+	}
+
+	for _, c := range tests {
+		t.Run(c.name, func(t *testing.T) {
+			if document := c.update.Document(); !reflect.DeepEqual(document, c.document) {
+				t.Fatal("Update Document", c.name, document)
+			} else {
+				fmt.Println("Update Document-", c.name, "Pass")
+			}
+		})
+	}
+}
+
+// TestStoreApplyUpdate runs several test cases to check the correctness of
+// the update operators of every DocumentStore implementation.
+func TestStoreApplyUpdate(t *testing.T) {
+	tests := []struct {
+		name      string
+		update    *Update
+		condition bson.M
+		found     bool
+	}{
+		{"Set back to zero", NewUpdate().Set("visits", 0).Set("active", false), bson.M{"visits": 0, "active": false}, true},
+		{"Nested field", NewUpdate().SetFields(Profile{Address: Address{City: "Pune"}}, "address.city"),
+			bson.M{"address.city": "Pune", "address.zip_code": "110001"}, true},
+		{"Increment", NewUpdate().Inc("visits", 2), bson.M{"visits": 2}, true},
+		{"Unset", NewUpdate().Unset("name"), bson.M{"name": "Richa"}, false},
+		{"Push", NewUpdate().Push("tags", "a").Push("roles", "admin", "author"), bson.M{"tags": "a", "roles": "author"}, true},
+	}
+
+	for storeName, store := range testStores(t) {
+		t.Run(storeName, func(t *testing.T) {
+			defer store.RemoveCollection("store_apply_update")
+			profile := Profile{Id: primitive.NewObjectID(), Name: "Richa", Visits: 5, Active: true,
+				Address: Address{City: "Delhi", ZipCode: "110001"}}
+			if _, err := store.InsertDocument("store_apply_update", profile); err != nil {
+				t.Fatal("InsertDocument", storeName, err)
+			}
+			for _, c := range tests {
+				err := store.ApplyUpdate(context.Background(), "store_apply_update", profile.Id.Hex(), c.update)
+				found, findErr := store.IsExistingDocument("store_apply_update", c.condition)
+				if err != nil || findErr != nil || found != c.found {
+					t.Fatal("ApplyUpdate", storeName, c.name, err, findErr)
+				} else {
+					fmt.Println("ApplyUpdate-", storeName, c.name, "Pass")
+				}
+			}
+		})
+	}
+}
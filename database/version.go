@@ -0,0 +1,102 @@
+package database
+
+import (
+	"github.com/programmer-richa/utility/constants"
+	"context"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// VersionField is the document field holding the version checked by versioned updates.
+// Models should declare it as an integer, e.g. Version int64 `bson:"version"`.
+const VersionField = "version"
+
+// ErrConflict is matched by errors.Is when a versioned update finds
+// that the document was changed since the expected version was read.
+var ErrConflict = errors.New(constants.VersionConflict)
+
+// ConflictError reports the document whose stored version did not match the expected version.
+type ConflictError struct {
+	Collection string
+	ID         string
+	Expected   int64
+}
+
+// Error returns the error message.
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s %s/%s expected version %d", constants.VersionConflict, e.Collection, e.ID, e.Expected)
+}
+
+// Is makes errors.Is(err, ErrConflict) report true.
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+// versionCondition returns the filter condition matching documents at version.
+// Documents stored before versioning was introduced have no version field and are at version 0.
+func versionCondition(version int64) interface{} {
+	if version == 0 {
+		return bson.M{"$in": bson.A{0, nil}}
+	}
+	return version
+}
+
+// UpdateDocumentVersioned is UpdateDocumentContext for versioned documents.
+// See ApplyVersionedUpdate.
+func (m *MongoHelper) UpdateDocumentVersioned(ctx context.Context, collectionName string, id string,
+	version int64, entry interface{}) (int64, error) {
+	if err := beforeUpdate(ctx, entry); err != nil {
+		return 0, err
+	}
+	newVersion, err := m.ApplyVersionedUpdate(ctx, collectionName, id, version, entryUpdate(ctx, entry))
+	if err != nil {
+		return 0, err
+	}
+	return newVersion, afterUpdate(ctx, entry)
+}
+
+// ApplyVersionedUpdate applies update to the document with the provided id if the document is at version.
+// The version is incremented by the update and the new version is returned.
+// It returns a *ConflictError, matching ErrConflict, if the document is at another version,
+// and an error matching ErrNotFound if there is no document with the id.
+func (m *MongoHelper) ApplyVersionedUpdate(ctx context.Context, collectionName string, id string,
+	version int64, update *Update) (newVersion int64, err error) {
+	defer classify(&err)
+	if m == nil {
+		return 0, errors.New(constants.NilMongoHelper)
+	}
+	if update == nil {
+		return 0, errors.New(constants.InvalidUpdate)
+	}
+	collection, release, err := m.collection(ctx, collectionName)
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+	docID, err := objectID(id)
+	if err != nil {
+		return 0, err
+	}
+	update = update.versioned()
+	result := collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": docID, VersionField: versionCondition(version)},
+		update.Document())
+	if err := result.Err(); err == mongo.ErrNoDocuments {
+		count, err := collection.CountDocuments(ctx, bson.M{"_id": docID})
+		if err != nil {
+			return 0, err
+		}
+		if count == 0 {
+			return 0, mongo.ErrNoDocuments
+		}
+		return 0, &ConflictError{Collection: collectionName, ID: id, Expected: version}
+	} else if err != nil {
+		return 0, err
+	}
+	if err := m.auditUpdate(ctx, collectionName, docID, result, update); err != nil {
+		return 0, err
+	}
+	return version + 1, nil
+}
@@ -0,0 +1,66 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Account is a versioned model.
+type Account struct {
+	Id      primitive.ObjectID `bson:"_id"`
+	Owner   string             `bson:"owner"`
+	Balance int                `bson:"balance"`
+	Version int64              `bson:"version"`
+}
+
+// TestStoreVersionedUpdate runs several test cases to check the correctness of
+// the optimistic concurrency control of every DocumentStore implementation.
+func TestStoreVersionedUpdate(t *testing.T) {
+	account := Account{Id: primitive.NewObjectID(), Owner: "Richa"}
+	legacyID := primitive.NewObjectID()
+	tests := []struct {
+		name       string
+		id         string
+		version    int64
+		newVersion int64
+		err        error
+	}{
+		{"First update", account.Id.Hex(), 0, 1, nil},
+		{"Stale version", account.Id.Hex(), 0, 0, ErrConflict},
+		{"Second update", account.Id.Hex(), 1, 2, nil},
+		{"Missing document", primitive.NewObjectID().Hex(), 0, 0, ErrNotFound},
+		{"Document without version", legacyID.Hex(), 0, 1, nil},
+	}
+
+	for storeName, store := range testStores(t) {
+		t.Run(storeName, func(t *testing.T) {
+			defer store.RemoveCollection("store_versioned")
+			if _, err := store.InsertDocument("store_versioned", account); err != nil {
+				t.Fatal("InsertDocument", storeName, err)
+			}
+			if _, err := store.InsertDocument("store_versioned", bson.M{"_id": legacyID, "owner": "Sahil"}); err != nil {
+				t.Fatal("InsertDocument", storeName, err)
+			}
+			for _, c := range tests {
+				entry := account
+				entry.Balance = int(c.version) * 10
+				newVersion, err := store.UpdateDocumentVersioned(context.Background(), "store_versioned", c.id, c.version, entry)
+				var conflict *ConflictError
+				if !errors.Is(err, c.err) || newVersion != c.newVersion ||
+					(c.err == ErrConflict && (!errors.As(err, &conflict) || conflict.Expected != c.version)) {
+					t.Fatal("UpdateDocumentVersioned", storeName, c.name, newVersion, err)
+				} else {
+					fmt.Println("UpdateDocumentVersioned-", storeName, c.name, "Pass")
+				}
+			}
+			found, err := store.IsExistingDocument("store_versioned", bson.M{"_id": account.Id, VersionField: 2, "balance": 10})
+			if err != nil || !found {
+				t.Fatal("UpdateDocumentVersioned did not store the version", storeName, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,296 @@
+package database
+
+import (
+	"github.com/programmer-richa/utility/constants"
+	"context"
+	"errors"
+	"net"
+	"time"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/auth"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/topology"
+)
+
+// Operation types reported by change streams.
+const (
+	OperationInsert     = "insert"
+	OperationUpdate     = "update"
+	OperationReplace    = "replace"
+	OperationDelete     = "delete"
+	OperationDrop       = "drop"
+	OperationRename     = "rename"
+	OperationInvalidate = "invalidate"
+)
+
+// ResumeTokenCollection stores the resume tokens of watchers with a ResumeTokenKey.
+const ResumeTokenCollection = "_resume_tokens"
+
+// Default reconnect delays of a watcher.
+const (
+	defaultWatchRetryDelay    = time.Second
+	defaultWatchMaxRetryDelay = time.Minute
+)
+
+// Server error codes of failures that reconnecting cannot recover from.
+const (
+	unauthorizedCode            = 13
+	authenticationFailedCode    = 18
+	changeStreamFatalCode       = 280
+	changeStreamHistoryLostCode = 286
+	changeStreamStandaloneCode  = 40573
+)
+
+// Namespace identifies the collection of a change event.
+type Namespace struct {
+	Database   string `bson:"db"`
+	Collection string `bson:"coll"`
+}
+
+// UpdateDescription lists the fields changed by an update event.
+type UpdateDescription struct {
+	UpdatedFields bson.M   `bson:"updatedFields"`
+	RemovedFields []string `bson:"removedFields"`
+}
+
+// ChangeEvent is a change reported by a change stream.
+type ChangeEvent struct {
+	// ResumeToken identifies the event. Watching can resume after it.
+	ResumeToken       bson.Raw            `bson:"_id"`
+	OperationType     string              `bson:"operationType"`
+	ClusterTime       primitive.Timestamp `bson:"clusterTime"`
+	Namespace         Namespace           `bson:"ns"`
+	DocumentKey       bson.M              `bson:"documentKey"`
+	FullDocument      bson.Raw            `bson:"fullDocument,omitempty"`
+	UpdateDescription *UpdateDescription  `bson:"updateDescription,omitempty"`
+}
+
+// Decode decodes the full document of the event into v.
+// Insert and replace events always carry the document, update events only
+// if the watcher was started with FullDocument set.
+func (e ChangeEvent) Decode(v interface{}) error {
+	if len(e.FullDocument) == 0 {
+		return errors.New(constants.NoFullDocument)
+	}
+	return bson.Unmarshal(e.FullDocument, v)
+}
+
+// ChangeHandler processes a change event. Returning an error stops the watcher.
+type ChangeHandler func(event ChangeEvent) error
+
+// WatchOptions configures a change stream watcher.
+type WatchOptions struct {
+	// Collection restricts the watcher to a collection. All collections of the database are watched if empty.
+	Collection string
+	// OperationTypes restricts the events to the listed operation types, e.g. OperationInsert.
+	OperationTypes []string
+	// FullDocument adds the current document to update events.
+	FullDocument bool
+	// ResumeTokenKey persists the resume token in ResumeTokenCollection under this key,
+	// so a restarted watcher continues after the last handled event. Tokens are not persisted if empty.
+	ResumeTokenKey string
+	// RetryDelay is the first delay before reconnecting after a failure. It doubles up to MaxRetryDelay.
+	RetryDelay    time.Duration
+	MaxRetryDelay time.Duration
+	// OnError is called with every failure the watcher retries, e.g. to log it.
+	OnError func(err error)
+}
+
+// pipeline returns the aggregation pipeline filtering the change stream.
+func (o WatchOptions) pipeline() mongo.Pipeline {
+	if len(o.OperationTypes) == 0 {
+		return mongo.Pipeline{}
+	}
+	return mongo.Pipeline{{{Key: "$match", Value: bson.M{"operationType": bson.M{"$in": o.OperationTypes}}}}}
+}
+
+// Watch calls handler for every change matching opts until ctx is cancelled or handler returns an error.
+// It reconnects after network and server failures, resuming after the last handled event.
+// Watch returns nil once ctx is cancelled, the error of handler, or a failure that reconnecting
+// cannot recover from: a server without change streams, an authentication or authorization
+// failure, or a change stream the server cannot resume.
+// Change streams require a replica set or a sharded cluster.
+func (m *MongoHelper) Watch(ctx context.Context, opts WatchOptions, handler ChangeHandler) error {
+	if m == nil {
+		return errors.New(constants.NilMongoHelper)
+	}
+	if handler == nil {
+		return errors.New(constants.NilChangeHandler)
+	}
+	if opts.RetryDelay <= 0 {
+		opts.RetryDelay = defaultWatchRetryDelay
+	}
+	if opts.MaxRetryDelay < opts.RetryDelay {
+		opts.MaxRetryDelay = defaultWatchMaxRetryDelay
+	}
+	var resumeToken bson.Raw
+	delay := opts.RetryDelay
+	for {
+		handled, err := m.watchOnce(ctx, opts, &resumeToken, handler)
+		var handlerErr *changeHandlerError
+		if errors.As(err, &handlerErr) {
+			return handlerErr.err
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		if isPermanentWatchError(err) {
+			return err
+		}
+		if err != nil && opts.OnError != nil {
+			opts.OnError(err)
+		}
+		if handled {
+			delay = opts.RetryDelay
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > opts.MaxRetryDelay {
+			delay = opts.MaxRetryDelay
+		}
+	}
+}
+
+// WatchChannel is Watch delivering the changes on a channel.
+// The channel is closed once ctx is cancelled or the watcher fails,
+// after the failure, if any, is sent on the error channel.
+func (m *MongoHelper) WatchChannel(ctx context.Context, opts WatchOptions) (<-chan ChangeEvent, <-chan error) {
+	events := make(chan ChangeEvent)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(events)
+		err := m.Watch(ctx, opts, func(event ChangeEvent) error {
+			select {
+			case events <- event:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		if err != nil && err != ctx.Err() {
+			errs <- err
+		}
+		close(errs)
+	}()
+	return events, errs
+}
+
+// changeHandlerError marks errors returned by a ChangeHandler, which stop the watcher.
+type changeHandlerError struct {
+	err error
+}
+
+func (e *changeHandlerError) Error() string {
+	return e.err.Error()
+}
+
+// watchOnce opens a change stream and handles its events until it fails.
+// resumeToken holds the token of the last handled event and is updated as events are handled.
+// It reports whether any event was handled.
+func (m *MongoHelper) watchOnce(ctx context.Context, opts WatchOptions, resumeToken *bson.Raw,
+	handler ChangeHandler) (handled bool, err error) {
+	client, err := m.GetSession()
+	if err != nil {
+		return false, err
+	}
+	// Close DB connection after this method is executed.
+	defer client.Disconnect(context.TODO())
+	db, err := m.GetDatabase(client)
+	if err != nil {
+		return false, err
+	}
+	tokens := db.Collection(ResumeTokenCollection)
+	if *resumeToken == nil && opts.ResumeTokenKey != "" {
+		if *resumeToken, err = loadResumeToken(ctx, tokens, opts.ResumeTokenKey); err != nil {
+			return false, err
+		}
+	}
+	streamOptions := options.ChangeStream()
+	if opts.FullDocument {
+		streamOptions.SetFullDocument(options.UpdateLookup)
+	}
+	if *resumeToken != nil {
+		// Unlike resumeAfter, startAfter also resumes after an invalidate event.
+		streamOptions.SetStartAfter(*resumeToken)
+	}
+	var stream *mongo.ChangeStream
+	if opts.Collection != "" {
+		stream, err = db.Collection(opts.Collection).Watch(ctx, opts.pipeline(), streamOptions)
+	} else {
+		stream, err = db.Watch(ctx, opts.pipeline(), streamOptions)
+	}
+	if err != nil {
+		return false, err
+	}
+	defer stream.Close(context.TODO())
+	for stream.Next(ctx) {
+		var event ChangeEvent
+		if err := stream.Decode(&event); err != nil {
+			return handled, err
+		}
+		if err := handler(event); err != nil {
+			return handled, &changeHandlerError{err}
+		}
+		handled = true
+		*resumeToken = event.ResumeToken
+		if opts.ResumeTokenKey != "" {
+			if err := saveResumeToken(ctx, tokens, opts.ResumeTokenKey, event.ResumeToken); err != nil {
+				return handled, err
+			}
+		}
+	}
+	return handled, stream.Err()
+}
+
+// isPermanentWatchError tests if err reports a failure of a change stream that reconnecting
+// cannot recover from.
+func isPermanentWatchError(err error) bool {
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) {
+		switch cmdErr.Code {
+		case unauthorizedCode, authenticationFailedCode, changeStreamFatalCode, changeStreamHistoryLostCode,
+			changeStreamStandaloneCode:
+			return true
+		}
+		return cmdErr.HasErrorLabel("NonResumableChangeStreamError")
+	}
+	// The driver of this version does not unwrap connection and authentication errors.
+	for err != nil {
+		var authErr *auth.Error
+		if errors.As(err, &authErr) {
+			var netErr net.Error
+			return !errors.As(authErr.Inner(), &netErr)
+		}
+		var connErr topology.ConnectionError
+		if !errors.As(err, &connErr) {
+			return false
+		}
+		err = connErr.Wrapped
+	}
+	return false
+}
+
+// loadResumeToken returns the resume token persisted under key, or nil if there is none.
+func loadResumeToken(ctx context.Context, tokens *mongo.Collection, key string) (bson.Raw, error) {
+	var stored struct {
+		Token bson.Raw `bson:"token"`
+	}
+	err := tokens.FindOne(ctx, bson.M{"_id": key}).Decode(&stored)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	return stored.Token, err
+}
+
+// saveResumeToken persists token under key.
+func saveResumeToken(ctx context.Context, tokens *mongo.Collection, key string, token bson.Raw) error {
+	_, err := tokens.UpdateOne(ctx, bson.M{"_id": key},
+		bson.M{"$set": bson.M{"token": token, "updatedAt": time.Now().UTC()}},
+		options.Update().SetUpsert(true))
+	return err
+}
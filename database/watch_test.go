@@ -0,0 +1,139 @@
+package database
+
+import (
+	"github.com/programmer-richa/utility/constants"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/topology"
+)
+
+// TestWatchOptions runs several test cases to check the correctness of
+// the change stream filter defined in database package.
+func TestWatchOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  WatchOptions
+		pipeline mongo.Pipeline
+	}{
+		{
+			"All operations",
+			WatchOptions{Collection: "users"},
+			mongo.Pipeline{},
+		}, {
+			"Inserts and deletes",
+			WatchOptions{Collection: "users", OperationTypes: []string{OperationInsert, OperationDelete}},
+			mongo.Pipeline{{{Key: "$match", Value: bson.M{"operationType": bson.M{"$in": []string{"insert", "delete"}}}}}},
+		},
+	}
+
+	for _, c := range tests {
+		t.Run(c.name, func(t *testing.T) {
+			if pipeline := c.options.pipeline(); !reflect.DeepEqual(pipeline, c.pipeline) {
+				t.Fatal("WatchOptions", c.name, pipeline)
+			} else {
+				fmt.Println("WatchOptions-", c.name, "Pass")
+			}
+		})
+	}
+}
+
+// TestChangeEventDecode runs several test cases to check the correctness of
+// the change event decoding functionality defined in database package.
+func TestChangeEventDecode(t *testing.T) {
+	document, _ := bson.Marshal(bson.M{"_id": "1", "name": "Richa"})
+	tests := []struct {
+		name  string
+		event ChangeEvent
+		valid bool
+	}{
+		{
+			"Full document",
+			ChangeEvent{OperationType: OperationInsert, FullDocument: document},
+			true,
+		}, {
+			"Missing document",
+			ChangeEvent{OperationType: OperationUpdate},
+			false,
+		},
+	}
+
+	for _, c := range tests {
+		t.Run(c.name, func(t *testing.T) {
+			var decoded struct {
+				ID   string `bson:"_id"`
+				Name string `bson:"name"`
+			}
+			err := c.event.Decode(&decoded)
+			if (err == nil) != c.valid || (c.valid && decoded.Name != "Richa") {
+				t.Fatal("ChangeEvent Decode", c.name, err)
+			} else {
+				fmt.Println("ChangeEvent Decode-", c.name, "Pass")
+			}
+		})
+	}
+}
+
+// TestWatchArguments checks that Watch rejects missing arguments without connecting.
+func TestWatchArguments(t *testing.T) {
+	handler := func(event ChangeEvent) error { return nil }
+	tests := []struct {
+		name    string
+		helper  *MongoHelper
+		handler ChangeHandler
+		message string
+	}{
+		{
+			"Nil helper",
+			nil,
+			handler,
+			constants.NilMongoHelper,
+		}, {
+			"Nil handler",
+			NewMongoHelper("root", "local", "localhost", "27017", "test"),
+			nil,
+			constants.NilChangeHandler,
+		},
+	}
+
+	for _, c := range tests {
+		t.Run(c.name, func(t *testing.T) {
+			err := c.helper.Watch(context.Background(), WatchOptions{}, c.handler)
+			if err == nil || err.Error() != c.message {
+				t.Fatal("Watch Function", c.name, err)
+			} else {
+				fmt.Println("Watch Function-", c.name, "Pass")
+			}
+		})
+	}
+}
+
+// TestPermanentWatchError runs several test cases to check the correctness of
+// the failures a watcher gives up on, defined in database package.
+func TestPermanentWatchError(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		permanent bool
+	}{
+		{"Standalone server", mongo.CommandError{Code: 40573, Name: "Location40573"}, true},
+		{"Authentication failure", mongo.CommandError{Code: 18, Name: "AuthenticationFailed"}, true},
+		{"Unauthorized", mongo.CommandError{Code: 13, Name: "Unauthorized"}, true},
+		{"Fatal change stream", mongo.CommandError{Code: 280, Name: "ChangeStreamFatalError"}, true},
+		{"Non-resumable label", mongo.CommandError{Code: 1, Labels: []string{"NonResumableChangeStreamError"}}, true},
+		{"Primary stepped down", mongo.CommandError{Code: 189, Name: "PrimarySteppedDown"}, false},
+		{"Network failure", topology.ConnectionError{Wrapped: errors.New("connection reset")}, false},
+		{"Cancelled context", context.Canceled, false},
+		{"Nil", nil, false},
+	}
+	for _, c := range tests {
+		if permanent := isPermanentWatchError(c.err); permanent != c.permanent {
+			t.Fatal(c.name, "expected", c.permanent, "got", permanent)
+		}
+		fmt.Println("PermanentWatchError-", c.name, "Pass")
+	}
+}
@@ -0,0 +1,122 @@
+package email
+
+import (
+	"github.com/programmer-richa/utility/constants"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// base64LineLength is the length of the lines of base64 encoded bodies, as required by RFC 2045.
+const base64LineLength = 76
+
+// Attachment is a file sent with a message, e.g. an invoice.
+type Attachment struct {
+	Filename    string
+	ContentType string
+	// ContentID identifies an inline attachment, which HTML bodies reference as cid:ContentID.
+	// It holds the characters of a msg-id without the angle brackets, e.g. logo@abc.com.
+	ContentID string
+	Data      []byte
+}
+
+// NewAttachment returns the attachment named filename holding the content of r.
+// The content type is detected from the extension of filename, or else from the content.
+func NewAttachment(filename string, r io.Reader) (Attachment, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return Attachment{}, err
+	}
+	contentType := mime.TypeByExtension(filepath.Ext(filename))
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+	return Attachment{Filename: filename, ContentType: contentType, Data: data}, nil
+}
+
+// NewFileAttachment returns the attachment holding the file at path, named after its base name.
+func NewFileAttachment(path string) (Attachment, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Attachment{}, err
+	}
+	defer file.Close()
+	return NewAttachment(filepath.Base(path), file)
+}
+
+// NewContentID returns a unique content id for an inline attachment, e.g. 3f2a...@localhost.
+func NewContentID() string {
+	random := make([]byte, 16)
+	rand.Read(random)
+	hostname, err := os.Hostname()
+	if err != nil || !isContentID(hostname) || strings.Contains(hostname, "@") {
+		hostname = "localhost"
+	}
+	return hex.EncodeToString(random) + "@" + hostname
+}
+
+// inline returns the attachment with a new content id unless set.
+// It returns an error if the content id holds characters a msg-id cannot hold.
+func (a Attachment) inline() (Attachment, error) {
+	if a.ContentID == "" {
+		a.ContentID = NewContentID()
+	} else if !isContentID(a.ContentID) {
+		return a, fmt.Errorf("%s %q", constants.InvalidContentID, a.ContentID)
+	}
+	return a, nil
+}
+
+// isContentID tests if id only holds the atext characters, dots and at signs of a msg-id, see RFC 5322.
+func isContentID(id string) bool {
+	if id == "" {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+			strings.ContainsRune("!#$%&'*+-/=?^_`{|}~.@", c)) {
+			return false
+		}
+	}
+	return true
+}
+
+// entity returns the MIME entity of the attachment, base64 encoded.
+func (a Attachment) entity(disposition string) *entity {
+	header := textproto.MIMEHeader{}
+	contentType := a.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	header.Set("Content-Type", contentType)
+	header.Set("Content-Transfer-Encoding", "base64")
+	if a.Filename != "" {
+		disposition = mime.FormatMediaType(disposition, map[string]string{"filename": a.Filename})
+	}
+	header.Set("Content-Disposition", disposition)
+	if a.ContentID != "" {
+		header.Set("Content-ID", "<"+a.ContentID+">")
+	}
+	return &entity{header: header, body: func(w io.Writer) error {
+		encoded := base64.StdEncoding.EncodeToString(a.Data)
+		for len(encoded) > 0 {
+			n := base64LineLength
+			if n > len(encoded) {
+				n = len(encoded)
+			}
+			if _, err := io.WriteString(w, encoded[:n]+"\r\n"); err != nil {
+				return err
+			}
+			encoded = encoded[n:]
+		}
+		return nil
+	}}
+}
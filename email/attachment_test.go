@@ -0,0 +1,156 @@
+package email
+
+import (
+	"github.com/programmer-richa/utility/constants"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// pngHeader is the signature of PNG images.
+var pngHeader = []byte("\x89PNG\r\n\x1a\n")
+
+// TestNewAttachment runs several test cases to check the content type detection of attachments.
+func TestNewAttachment(t *testing.T) {
+	tests := []struct {
+		name        string
+		filename    string
+		data        []byte
+		contentType string
+	}{
+		{"Extension", "invoice.pdf", []byte("%PDF-1.4"), "application/pdf"},
+		{"Content", "logo", pngHeader, "image/png"},
+		{"Unknown", "data", []byte{0, 1, 2}, "application/octet-stream"},
+	}
+
+	for _, c := range tests {
+		t.Run(c.name, func(t *testing.T) {
+			attachment, err := NewAttachment(c.filename, bytes.NewReader(c.data))
+			if err != nil || attachment.ContentType != c.contentType || !bytes.Equal(attachment.Data, c.data) {
+				t.Fatal("NewAttachment", c.name, attachment.ContentType, err)
+			} else {
+				fmt.Println("NewAttachment-", c.name, "Pass")
+			}
+		})
+	}
+}
+
+// TestMessageAttachments checks the MIME structure of a message with attachments and inline images.
+func TestMessageAttachments(t *testing.T) {
+	dir, err := ioutil.TempDir("", "attachments")
+	if err != nil {
+		t.Fatal("TempDir", err)
+	}
+	defer os.RemoveAll(dir)
+	invoice := bytes.Repeat([]byte("%PDF-1.4 invoice "), 20)
+	if err := ioutil.WriteFile(filepath.Join(dir, "invoice.pdf"), invoice, 0600); err != nil {
+		t.Fatal("WriteFile", err)
+	}
+	message := NewMessage("richa@abc.com", []string{"a@abc.com"}, "Invoice")
+	message.ContentType = MIME_HTML
+	message.PlainText = []byte("Your invoice")
+	cid, err := message.Embed("logo.png", bytes.NewReader(pngHeader))
+	if err != nil || !isContentID(cid) || !strings.Contains(cid, "@") || strings.HasPrefix(cid, "logo.png") {
+		t.Fatal("Embed", cid, err)
+	}
+	message.Body = []byte(`<img src="cid:` + cid + `"/><p>Your invoice</p>`)
+	if err := message.AttachFile(filepath.Join(dir, "invoice.pdf")); err != nil {
+		t.Fatal("AttachFile", err)
+	}
+	data, err := message.Bytes()
+	if err != nil {
+		t.Fatal("Bytes", err)
+	}
+	for _, line := range strings.SplitAfter(string(data), "\r\n") {
+		if len(line) > 2+maxLineLength {
+			t.Fatal("Message line", line)
+		}
+	}
+	parsed, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal("ReadMessage", err)
+	}
+	structure := describe(t, parsed.Header.Get("Content-Type"), parsed.Header.Get("Content-Transfer-Encoding"), "", "", ioutil.NopCloser(parsed.Body))
+	expected := "multipart/mixed(multipart/related(multipart/alternative(text/plain,text/html),image/png inline <" + cid + ">),application/pdf attachment)"
+	if structure != expected {
+		t.Fatal("Message structure", structure)
+	}
+	fmt.Println("Message attachments- Pass")
+}
+
+// TestContentID runs several test cases to check the content ids of inline attachments.
+func TestContentID(t *testing.T) {
+	if first, second := NewContentID(), NewContentID(); first == second || !isContentID(first) {
+		t.Fatal("NewContentID", first, second)
+	}
+	tests := []struct {
+		name      string
+		contentID string
+		valid     bool
+	}{
+		{"Generated", "", true},
+		{"Caller supplied", "logo@abc.com", true},
+		{"Space", "my logo@abc.com", false},
+		{"Angle brackets", "<logo@abc.com>", false},
+		{"Header injection", "logo@abc.com>\r\nBcc: x@abc.com", false},
+	}
+
+	for _, c := range tests {
+		t.Run(c.name, func(t *testing.T) {
+			message := NewMessage("richa@abc.com", []string{"a@abc.com"}, "Logo")
+			message.Inline = []Attachment{{Filename: "logo.png", ContentType: "image/png", ContentID: c.contentID, Data: pngHeader}}
+			data, err := message.Bytes()
+			if c.valid && (err != nil || !strings.Contains(string(data), "Content-Id: <")) ||
+				!c.valid && (err == nil || !strings.HasPrefix(err.Error(), constants.InvalidContentID)) {
+				t.Fatal("Content id", c.name, err)
+			} else {
+				fmt.Println("Content id-", c.name, "Pass")
+			}
+		})
+	}
+}
+
+// describe returns the structure of a MIME entity, checking that attachments decode to their content.
+func describe(t *testing.T, contentType string, encoding string, disposition string, contentID string, body interface {
+	Read([]byte) (int, error)
+}) string {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		t.Fatal("ParseMediaType", contentType, err)
+	}
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		var parts []string
+		for {
+			part, err := reader.NextPart()
+			if err != nil {
+				break
+			}
+			parts = append(parts, describe(t, part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"),
+				part.Header.Get("Content-Disposition"), part.Header.Get("Content-ID"), part))
+		}
+		return mediaType + "(" + strings.Join(parts, ",") + ")"
+	}
+	if disposition == "" {
+		return mediaType
+	}
+	content, _ := ioutil.ReadAll(body)
+	decoded, err := base64.StdEncoding.DecodeString(strings.Replace(string(content), "\r\n", "", -1))
+	if encoding != "base64" || err != nil || len(decoded) == 0 {
+		t.Fatal("Attachment encoding", mediaType, encoding, err)
+	}
+	dispositionType, _, _ := mime.ParseMediaType(disposition)
+	description := mediaType + " " + dispositionType
+	if contentID != "" {
+		description += " " + contentID
+	}
+	return description
+}
@@ -0,0 +1,357 @@
+package email
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// InlineCSS moves the rules of the style elements of the HTML document into the style attributes
+// of the elements they select, as many mail clients ignore style elements.
+// Type, class and id selectors combined with descendant and child combinators are inlined.
+// Other rules, e.g. @media queries or :hover selectors, are kept in the style elements,
+// which are removed once empty. Declarations of style attributes take precedence over the rules.
+func InlineCSS(document string) (string, error) {
+	root, err := html.Parse(strings.NewReader(document))
+	if err != nil {
+		return "", err
+	}
+	var rules []cssRule
+	var styles []*html.Node
+	var elements []*html.Node
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.DataAtom {
+			case atom.Style:
+				styles = append(styles, n)
+				return
+			case atom.Head, atom.Script:
+				for c := n.FirstChild; c != nil; c = c.NextSibling {
+					if c.Type == html.ElementNode && c.DataAtom == atom.Style {
+						styles = append(styles, c)
+					}
+				}
+				return
+			}
+			elements = append(elements, n)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(root)
+	for _, style := range styles {
+		var css strings.Builder
+		for c := style.FirstChild; c != nil; c = c.NextSibling {
+			css.WriteString(c.Data)
+		}
+		inlined, kept := parseStylesheet(css.String(), len(rules))
+		rules = append(rules, inlined...)
+		if strings.TrimSpace(kept) == "" {
+			style.Parent.RemoveChild(style)
+			continue
+		}
+		for style.FirstChild != nil {
+			style.RemoveChild(style.FirstChild)
+		}
+		style.AppendChild(&html.Node{Type: html.TextNode, Data: kept})
+	}
+	for _, element := range elements {
+		var matched []cssRule
+		for _, rule := range rules {
+			if rule.selector.matches(element) {
+				matched = append(matched, rule)
+			}
+		}
+		if len(matched) == 0 {
+			continue
+		}
+		sort.SliceStable(matched, func(i, j int) bool {
+			if matched[i].selector.specificity != matched[j].selector.specificity {
+				return matched[i].selector.specificity < matched[j].selector.specificity
+			}
+			return matched[i].order < matched[j].order
+		})
+		var declarations []cssDeclaration
+		for _, rule := range matched {
+			declarations = append(declarations, rule.declarations...)
+		}
+		index := -1
+		for i, attribute := range element.Attr {
+			if strings.EqualFold(attribute.Key, "style") {
+				index = i
+				declarations = append(declarations, parseDeclarations(attribute.Val)...)
+			}
+		}
+		style := formatDeclarations(declarations)
+		if index < 0 {
+			element.Attr = append(element.Attr, html.Attribute{Key: "style", Val: style})
+		} else {
+			element.Attr[index].Val = style
+		}
+	}
+	buffer := new(bytes.Buffer)
+	if err := html.Render(buffer, root); err != nil {
+		return "", err
+	}
+	return buffer.String(), nil
+}
+
+// cssRule is a rule of a stylesheet with a single selector.
+type cssRule struct {
+	selector     cssSelector
+	declarations []cssDeclaration
+	// order is the position of the rule in the stylesheets, which breaks ties of specificity.
+	order int
+}
+
+// cssDeclaration is a property of a rule, e.g. color: #FFFFFF.
+type cssDeclaration struct {
+	property string
+	value    string
+}
+
+// cssSelector is a sequence of compound selectors, e.g. table.wrapper > td .button.
+type cssSelector struct {
+	compounds []cssCompound
+	// child tells if each compound must be a child, rather than a descendant, of the previous one.
+	child       []bool
+	specificity int
+}
+
+// cssCompound selects elements by type, id and classes, e.g. a.button.
+type cssCompound struct {
+	tag     string
+	id      string
+	classes []string
+}
+
+// parseStylesheet returns the rules of css that can be inlined, numbered from order, and the css
+// of the rules that cannot.
+func parseStylesheet(css string, order int) ([]cssRule, string) {
+	for {
+		start := strings.Index(css, "/*")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(css[start+2:], "*/")
+		if end < 0 {
+			css = css[:start]
+			break
+		}
+		css = css[:start] + css[start+2+end+2:]
+	}
+	var rules []cssRule
+	kept := new(strings.Builder)
+	for {
+		css = strings.TrimSpace(css)
+		if css == "" {
+			break
+		}
+		if css[0] == '@' {
+			end := atRuleEnd(css)
+			kept.WriteString(css[:end] + "\n")
+			css = css[end:]
+			continue
+		}
+		open := strings.IndexByte(css, '{')
+		if open < 0 {
+			break
+		}
+		closing := strings.IndexByte(css[open:], '}')
+		if closing < 0 {
+			break
+		}
+		selectors, body := css[:open], css[open+1:open+closing]
+		css = css[open+closing+1:]
+		declarations := parseDeclarations(body)
+		for _, text := range strings.Split(selectors, ",") {
+			text = strings.TrimSpace(text)
+			selector, ok := parseSelector(text)
+			if !ok {
+				kept.WriteString(text + " {" + body + "}\n")
+				continue
+			}
+			rules = append(rules, cssRule{selector: selector, declarations: declarations, order: order})
+			order++
+		}
+	}
+	return rules, kept.String()
+}
+
+// atRuleEnd returns the length of the at-rule css starts with, including its block if any.
+func atRuleEnd(css string) int {
+	depth := 0
+	for i := 0; i < len(css); i++ {
+		switch css[i] {
+		case ';':
+			if depth == 0 {
+				return i + 1
+			}
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return len(css)
+}
+
+// parseDeclarations returns the declarations of a rule body or style attribute.
+func parseDeclarations(body string) []cssDeclaration {
+	var declarations []cssDeclaration
+	for _, text := range strings.Split(body, ";") {
+		colon := strings.IndexByte(text, ':')
+		if colon < 0 {
+			continue
+		}
+		property := strings.ToLower(strings.TrimSpace(text[:colon]))
+		value := strings.TrimSpace(text[colon+1:])
+		if property != "" && value != "" {
+			declarations = append(declarations, cssDeclaration{property: property, value: value})
+		}
+	}
+	return declarations
+}
+
+// formatDeclarations returns the style attribute setting declarations, later declarations
+// of a property overriding earlier ones.
+func formatDeclarations(declarations []cssDeclaration) string {
+	values := map[string]string{}
+	var properties []string
+	for _, declaration := range declarations {
+		if _, ok := values[declaration.property]; !ok {
+			properties = append(properties, declaration.property)
+		}
+		values[declaration.property] = declaration.value
+	}
+	style := make([]string, len(properties))
+	for i, property := range properties {
+		style[i] = property + ": " + values[property]
+	}
+	return strings.Join(style, "; ")
+}
+
+// parseSelector parses text as a selector, returning false if it cannot be inlined.
+func parseSelector(text string) (cssSelector, bool) {
+	var selector cssSelector
+	if text == "" || strings.ContainsAny(text, ":[]+~()") {
+		return selector, false
+	}
+	child := false
+	for _, field := range strings.Fields(strings.Replace(text, ">", " > ", -1)) {
+		if field == ">" {
+			if child || len(selector.compounds) == 0 {
+				return selector, false
+			}
+			child = true
+			continue
+		}
+		compound, specificity, ok := parseCompound(field)
+		if !ok {
+			return selector, false
+		}
+		selector.compounds = append(selector.compounds, compound)
+		selector.child = append(selector.child, child)
+		selector.specificity += specificity
+		child = false
+	}
+	return selector, !child && len(selector.compounds) > 0
+}
+
+// parseCompound parses a compound selector and returns its specificity.
+func parseCompound(text string) (cssCompound, int, bool) {
+	var compound cssCompound
+	specificity := 0
+	end := strings.IndexAny(text, ".#")
+	if end < 0 {
+		end = len(text)
+	}
+	compound.tag = strings.ToLower(text[:end])
+	if compound.tag != "" && compound.tag != "*" {
+		specificity++
+	}
+	for text = text[end:]; text != ""; {
+		kind := text[0]
+		end := strings.IndexAny(text[1:], ".#")
+		if end < 0 {
+			end = len(text) - 1
+		}
+		name := text[1 : end+1]
+		if name == "" {
+			return compound, 0, false
+		}
+		if kind == '#' {
+			compound.id = name
+			specificity += 10000
+		} else {
+			compound.classes = append(compound.classes, name)
+			specificity += 100
+		}
+		text = text[end+1:]
+	}
+	return compound, specificity, true
+}
+
+// matches tests if the selector selects element.
+func (s cssSelector) matches(element *html.Node) bool {
+	return s.matchesFrom(len(s.compounds)-1, element)
+}
+
+// matchesFrom tests if element is selected by the compounds of the selector up to index i.
+func (s cssSelector) matchesFrom(i int, element *html.Node) bool {
+	if !s.compounds[i].matches(element) {
+		return false
+	}
+	if i == 0 {
+		return true
+	}
+	for parent := element.Parent; parent != nil && parent.Type == html.ElementNode; parent = parent.Parent {
+		if s.matchesFrom(i-1, parent) {
+			return true
+		}
+		if s.child[i] {
+			break
+		}
+	}
+	return false
+}
+
+// matches tests if the compound selects element.
+func (c cssCompound) matches(element *html.Node) bool {
+	if c.tag != "" && c.tag != "*" && c.tag != element.Data {
+		return false
+	}
+	var id string
+	var classes []string
+	for _, attribute := range element.Attr {
+		switch attribute.Key {
+		case "id":
+			id = attribute.Val
+		case "class":
+			classes = strings.Fields(attribute.Val)
+		}
+	}
+	if c.id != "" && c.id != id {
+		return false
+	}
+	for _, class := range c.classes {
+		found := false
+		for _, name := range classes {
+			if name == class {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,50 @@
+package email
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// TestInlineCSS runs several test cases to check that the rules of style elements
+// are moved into style attributes.
+func TestInlineCSS(t *testing.T) {
+	tests := []struct {
+		name     string
+		html     string
+		expected string
+	}{
+		{
+			"Type, class and id selectors",
+			`<style>p{color:red} .note{font-size:12px} #top{margin:0}</style><p class="note" id="top">Hi</p>`,
+			`<body><p class="note" id="top" style="color: red; font-size: 12px; margin: 0">Hi</p></body>`,
+		}, {
+			"Specificity and order",
+			`<style>.button a{color:#FFF} a{color:red;font-weight:bold} a{color:blue}</style><div class="button"><a>Go</a></div><a>Out</a>`,
+			`<div class="button"><a style="color: #FFF; font-weight: bold">Go</a></div><a style="color: blue; font-weight: bold">Out</a>`,
+		}, {
+			"Style attribute wins",
+			`<style>td{padding:10px;color:black}</style><table><tr><td style="padding: 40px;">A</td></tr></table>`,
+			`<td style="padding: 40px; color: black">A</td>`,
+		}, {
+			"Child combinator",
+			`<style>div > b{color:red}</style><div><b>A</b><i><b>B</b></i></div>`,
+			`<div><b style="color: red">A</b><i><b>B</b></i></div>`,
+		}, {
+			"Media queries and pseudo-classes are kept",
+			"<style>/* mail */ a:hover{color:red} @media (max-width: 600px){p{color:blue}} p{margin:0}</style><p>Hi</p>",
+			"<style>a:hover {color:red}\n@media (max-width: 600px){p{color:blue}}\n</style></head><body><p style=\"margin: 0\">Hi</p>",
+		},
+	}
+
+	for _, c := range tests {
+		t.Run(c.name, func(t *testing.T) {
+			inlined, err := InlineCSS(c.html)
+			if err != nil || !strings.Contains(inlined, c.expected) {
+				t.Fatalf("InlineCSS %s %v %q", c.name, err, inlined)
+			} else {
+				fmt.Println("InlineCSS-", c.name, "Pass")
+			}
+		})
+	}
+}
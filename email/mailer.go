@@ -5,23 +5,38 @@ import (
 	"github.com/programmer-richa/utility/constants"
 	"bytes"
 	"errors"
-	"fmt"
 	"html/template"
-	"net/smtp"
+	"io"
+	"net/textproto"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
 )
 
 // Mime type supported by email
 const (
 	// For sending HTML content (Passed as an argument to send function)
-	MIME_HTML = "MIME-version: 1.0;\nContent-Type: text/html; charset=\"UTF-8\";\n\n"
+	MIME_HTML = "text/html; charset=\"UTF-8\""
+	// For sending plain text content (Passed as an argument to send function)
+	MIME_TEXT = "text/plain; charset=\"UTF-8\""
 )
 
 // MailRequest represents struct to map email receiver(s), subject and body
 type MailRequest struct {
 	from    string
 	to      []string
+	cc      []string
+	bcc     []string
+	replyTo []string
 	subject string
 	body    string
+	// text is the plain text version of body rendered from a companion template
+	text string
+	// header holds additional headers of the email
+	header textproto.MIMEHeader
+	// attachments and inline attachments of the email
+	attachments []Attachment
+	inline      []Attachment
 	// The following parameters are for mail server authentication
 	server   string
 	port     int
@@ -29,23 +44,175 @@ type MailRequest struct {
 	password string
 	// Template pointer to parse template files
 	tpl *template.Template
+	// Template pointer to parse the companion plain text templates, see SetTextTemplate
+	textTpl *texttemplate.Template
+	// registry renders the templates instead of tpl if set with SetRegistry
+	registry *Registry
+	// transport delivers the email, an SMTPTransport for the server unless set with SetTransport
+	transport Transport
 }
 
 // NewMailRequest returns pointer to MailRequest
 func NewMailRequest(to []string, subject string, server string, port int, username string, password string, tpl *template.Template) *MailRequest {
 	return &MailRequest{
-		to:       to,
-		subject:  subject,
-		server:   server,
-		port:     port,
-		username: username,
-		password: password,
-		tpl:      tpl,
+		to:        to,
+		subject:   subject,
+		server:    server,
+		port:      port,
+		username:  username,
+		password:  password,
+		tpl:       tpl,
+		transport: NewSMTPTransport(server, port, username, password),
 	}
 }
 
+// SetTransport makes the request deliver the email through transport,
+// e.g. a MemoryTransport in tests, instead of the SMTP server.
+func (r *MailRequest) SetTransport(transport Transport) {
+	r.transport = transport
+}
+
+// SetFrom sets the sender of the email, e.g. "Richa <programmer.richa@gmail.com>".
+// The username of the mail server is used by default if it is an address. Otherwise, e.g. for
+// an API key, sending fails with the constants.NoSender error unless the sender is set.
+func (r *MailRequest) SetFrom(from string) {
+	r.from = from
+}
+
+// SetCc sets the carbon copy receivers of the email.
+func (r *MailRequest) SetCc(cc []string) {
+	r.cc = cc
+}
+
+// SetBcc sets the blind carbon copy receivers of the email, which are hidden from the other receivers.
+func (r *MailRequest) SetBcc(bcc []string) {
+	r.bcc = bcc
+}
+
+// SetReplyTo sets the addresses replies to the email should be sent to.
+func (r *MailRequest) SetReplyTo(replyTo []string) {
+	r.replyTo = replyTo
+}
+
+// SetHeader sets an additional header of the email, e.g. List-Unsubscribe.
+func (r *MailRequest) SetHeader(name string, value string) {
+	if r.header == nil {
+		r.header = textproto.MIMEHeader{}
+	}
+	r.header.Set(name, value)
+}
+
+// Message returns the email with the body parsed by ParseTemplate, of content type mime.
+// HTML emails are sent as a multipart/alternative with a plain text version, rendered
+// from the companion template or else converted from the HTML with HTMLToText.
+func (r *MailRequest) Message(mime string) *Message {
+	from := r.from
+	if from == "" {
+		if _, err := parseAddress(r.username); err == nil {
+			from = r.username
+		}
+	}
+	var plainText []byte
+	if strings.HasPrefix(mime, "text/html") {
+		if r.text != "" {
+			plainText = []byte(r.text)
+		} else {
+			plainText = []byte(HTMLToText(r.body))
+		}
+	}
+	return &Message{
+		From:        from,
+		To:          r.to,
+		Cc:          r.cc,
+		Bcc:         r.bcc,
+		ReplyTo:     r.replyTo,
+		Subject:     r.subject,
+		Header:      r.header,
+		ContentType: mime,
+		Body:        []byte(r.body),
+		PlainText:   plainText,
+		Attachments: r.attachments,
+		Inline:      r.inline,
+	}
+}
+
+// Attach adds a file named filename holding the content of reader to the email, see NewAttachment.
+func (r *MailRequest) Attach(filename string, reader io.Reader) error {
+	attachment, err := NewAttachment(filename, reader)
+	if err != nil {
+		return err
+	}
+	r.attachments = append(r.attachments, attachment)
+	return nil
+}
+
+// AttachFile adds the file at path to the email, see NewFileAttachment.
+func (r *MailRequest) AttachFile(path string) error {
+	attachment, err := NewFileAttachment(path)
+	if err != nil {
+		return err
+	}
+	r.attachments = append(r.attachments, attachment)
+	return nil
+}
+
+// Embed adds an image named filename holding the content of reader to the email, to be
+// displayed by the HTML template. It returns the new content id of the image, which templates
+// reference as cid:<id>, e.g. <img src="cid:{{ .Logo }}"/> with the id passed as Logo.
+func (r *MailRequest) Embed(filename string, reader io.Reader) (string, error) {
+	attachment, err := NewAttachment(filename, reader)
+	if err != nil {
+		return "", err
+	}
+	if attachment, err = attachment.inline(); err != nil {
+		return "", err
+	}
+	r.inline = append(r.inline, attachment)
+	return attachment.ContentID, nil
+}
+
+// EmbedFile adds the image at path to the email, see Embed.
+func (r *MailRequest) EmbedFile(path string) (string, error) {
+	attachment, err := NewFileAttachment(path)
+	if err != nil {
+		return "", err
+	}
+	if attachment, err = attachment.inline(); err != nil {
+		return "", err
+	}
+	r.inline = append(r.inline, attachment)
+	return attachment.ContentID, nil
+}
+
+// SetTextTemplate sets the templates of the plain text versions of HTML emails.
+// The companion of an HTML template is named after it with the .txt extension,
+// e.g. account_verification.txt for account_verification.gohtml.
+func (r *MailRequest) SetTextTemplate(tpl *texttemplate.Template) {
+	r.textTpl = tpl
+}
+
+// SetRegistry makes the request render its templates with registry, including their layouts,
+// subject lines and plain text companions, instead of the template pointer.
+func (r *MailRequest) SetRegistry(registry *Registry) {
+	r.registry = registry
+}
+
 // ParseTemplate binds the data passed with the email template file.
+// The companion plain text template of the file, if any, is bound as well.
+// With a registry, the subject line of the template is used unless the request has a subject.
 func (r *MailRequest) ParseTemplate(fileName string, data interface{}) error {
+	if r.registry != nil {
+		rendered, err := r.registry.Render(fileName, data)
+		if err != nil {
+			return err
+		}
+		r.body = rendered.HTML
+		r.text = rendered.Text
+		if r.subject == "" {
+			r.subject = rendered.Subject
+		}
+		return nil
+	}
 	if r.tpl == nil {
 		// Return custom error
 		return errors.New(constants.NilTpl)
@@ -55,15 +222,25 @@ func (r *MailRequest) ParseTemplate(fileName string, data interface{}) error {
 		return err
 	}
 	r.body = buffer.String()
+	r.text = ""
+	companion := strings.TrimSuffix(fileName, filepath.Ext(fileName)) + ".txt"
+	if r.textTpl != nil && r.textTpl.Lookup(companion) != nil {
+		buffer.Reset()
+		if err := r.textTpl.ExecuteTemplate(buffer, companion, data); err != nil {
+			return err
+		}
+		r.text = buffer.String()
+	}
 	return nil
 }
 
-// SendMail sends the email and returns true if email is sent successfully
+// SendMail sends the email with a body of content type mime, such as MIME_HTML,
+// to the To, Cc and Bcc receivers.
 func (r *MailRequest) SendMail(mime string) error {
-	body := "To: " + r.to[0] + "\r\nSubject: " + r.subject + "\r\n" + mime + "\r\n" + r.body
-	SMTP := fmt.Sprintf("%s:%d", r.server, r.port)
-	err := smtp.SendMail(SMTP, smtp.PlainAuth("", r.username, r.password, r.server), r.username, r.to, []byte(body))
-	return err
+	if r.transport == nil {
+		return errors.New(constants.NilTransport)
+	}
+	return SendMessage(r.transport, r.Message(mime))
 }
 
 // Send sends the email and saves the result in the log file
@@ -2,13 +2,22 @@ package email
 
 import (
 	"github.com/programmer-richa/utility/constants"
+	"bytes"
+	"errors"
 	"fmt"
 	"html/template"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
 	"testing"
+	texttemplate "text/template"
 )
 
 // TestMailer runs several test cases to check the correctness of
 //the Mail functionality defined in functions package.
+// Emails are delivered to a MemoryTransport, so no mail server is needed.
 func TestMailer(t *testing.T) {
 	username := constants.MailUsername
 	password := constants.MailPassword
@@ -33,6 +42,21 @@ func TestMailer(t *testing.T) {
 		"Test Mail",
 		server, port, username+"invalid", password, tpl)
 
+	registry, err := NewDefaultRegistry(RegistryOptions{})
+	if err != nil {
+		t.Fatal("NewDefaultRegistry", err)
+	}
+	request4 := NewMailRequest([]string{"programmer.richa@gmail.com"},
+		"Test Mail",
+		server, port, username, password, nil)
+	request4.SetRegistry(registry)
+
+	transport := NewMemoryTransport()
+	request1.SetTransport(transport)
+	request2.SetTransport(transport)
+	request3.SetTransport(&MemoryTransport{Err: errors.New("535 authentication failed")})
+	request4.SetTransport(transport)
+
 	tests := []struct {
 		name     string
 		data     map[string]string
@@ -61,12 +85,23 @@ func TestMailer(t *testing.T) {
 			"account_verification.gohtml",
 			false,
 		},
+		{
+			"Registry template for email parsing",
+			data,
+			request4,
+			"account_verification.gohtml",
+			true,
+		},
 	}
 
 	for _, c := range tests {
 		t.Run(c.name, func(t *testing.T) {
+			transport.Reset()
 			err, result := c.mail.Send(c.filename, MIME_HTML, data)
-			if result != c.valid {
+			sent := len(transport.Messages()) == 1 &&
+				bytes.Contains(transport.Messages()[0].Message, []byte("Subject: Test Mail")) &&
+				bytes.Contains(transport.Messages()[0].Message, []byte("Activate NOW"))
+			if result != c.valid || sent != c.valid {
 				t.Fatal("Mailer Function ", c.name, result, err)
 			} else {
 				fmt.Println("Mailer Function-", c.name, "Pass")
@@ -74,3 +109,101 @@ func TestMailer(t *testing.T) {
 		})
 	}
 }
+
+// TestMailerAlternative checks that HTML emails carry a plain text version,
+// rendered from the companion template or converted from the HTML.
+func TestMailerAlternative(t *testing.T) {
+	data := map[string]string{"URL": "http://abc.com/verify", "Name": "Richa"}
+	tpl := template.Must(template.New("").ParseGlob("../templates/emails/*.gohtml"))
+	textTpl := texttemplate.Must(texttemplate.New("account_verification.txt").Parse("Hi {{ .Name }}, open {{ .URL }}"))
+	tests := []struct {
+		name    string
+		textTpl *texttemplate.Template
+		text    string
+	}{
+		{"Converted HTML", nil, "Activate NOW (http://abc.com/verify)"},
+		{"Companion template", textTpl, "Hi Richa, open http://abc.com/verify"},
+	}
+
+	for _, c := range tests {
+		t.Run(c.name, func(t *testing.T) {
+			transport := NewMemoryTransport()
+			request := NewMailRequest([]string{"a@abc.com"}, "Test Mail", "", 0, "richa@abc.com", "", tpl)
+			request.SetTransport(transport)
+			request.SetTextTemplate(c.textTpl)
+			if err, _ := request.Send("account_verification.gohtml", MIME_HTML, data); err != nil {
+				t.Fatal("Send", c.name, err)
+			}
+			message, err := mail.ReadMessage(bytes.NewReader(transport.Messages()[0].Message))
+			if err != nil {
+				t.Fatal("ReadMessage", c.name, err)
+			}
+			mediaType, params, _ := mime.ParseMediaType(message.Header.Get("Content-Type"))
+			if mediaType != "multipart/alternative" {
+				t.Fatal("Content-Type", c.name, mediaType)
+			}
+			reader := multipart.NewReader(message.Body, params["boundary"])
+			var types []string
+			var text string
+			for {
+				part, err := reader.NextPart()
+				if err != nil {
+					break
+				}
+				content, _ := ioutil.ReadAll(part)
+				types = append(types, part.Header.Get("Content-Type"))
+				if part.Header.Get("Content-Type") == MIME_TEXT {
+					text = string(content)
+				}
+			}
+			if strings.Join(types, ",") != MIME_TEXT+","+MIME_HTML || !strings.Contains(text, c.text) {
+				t.Fatal("Alternative", c.name, types, text)
+			} else {
+				fmt.Println("Mailer alternative-", c.name, "Pass")
+			}
+		})
+	}
+}
+
+// TestMailerFrom runs several test cases to check that the username of the mail server
+// is the default sender only if it is an address.
+func TestMailerFrom(t *testing.T) {
+	tpl := template.Must(template.New("").ParseGlob("../templates/emails/*.gohtml"))
+	data := map[string]string{"URL": "http://abc.com/verify", "Name": "Richa"}
+	tests := []struct {
+		name     string
+		username string
+		from     string
+		header   string
+		err      string
+	}{
+		{"Address username", "richa@abc.com", "", "<richa@abc.com>", ""},
+		{"API key username", "apikey", "", "", constants.NoSender},
+		{"API key username with sender", "apikey", "Richa <richa@abc.com>", "\"Richa\" <richa@abc.com>", ""},
+	}
+
+	for _, c := range tests {
+		t.Run(c.name, func(t *testing.T) {
+			transport := NewMemoryTransport()
+			request := NewMailRequest([]string{"a@abc.com"}, "Test Mail", "", 0, c.username, "", tpl)
+			request.SetTransport(transport)
+			request.SetFrom(c.from)
+			err, _ := request.Send("account_verification.gohtml", MIME_HTML, data)
+			if c.err != "" {
+				if err == nil || err.Error() != c.err || len(transport.Messages()) != 0 {
+					t.Fatal("From", c.name, err)
+				}
+				fmt.Println("Mailer from-", c.name, "Pass")
+				return
+			}
+			if err != nil {
+				t.Fatal("From", c.name, err)
+			}
+			message, err := mail.ReadMessage(bytes.NewReader(transport.Messages()[0].Message))
+			if err != nil || message.Header.Get("From") != c.header {
+				t.Fatal("From", c.name, message.Header.Get("From"), err)
+			}
+			fmt.Println("Mailer from-", c.name, "Pass")
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package email
+
+import (
+	"github.com/programmer-richa/utility/constants"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultMergeConcurrency is the number of emails a Merge sends concurrently unless set with MergeOptions.
+const DefaultMergeConcurrency = 4
+
+// MergeRecipient is a recipient of a mail merge with the data its email is rendered with.
+type MergeRecipient struct {
+	// To is the address of the recipient, e.g. "Richa <programmer.richa@gmail.com>".
+	To   string
+	Data interface{}
+}
+
+// MergeIterator yields the recipients of a mail merge one at a time, e.g. from a database cursor,
+// so that large lists are not held in memory. Next returns false once the recipients are exhausted
+// or an error occurred, which Err then returns. It is only called by a single goroutine.
+type MergeIterator interface {
+	Next() (MergeRecipient, bool)
+	Err() error
+}
+
+// sliceIterator yields the recipients of a slice.
+type sliceIterator struct {
+	recipients []MergeRecipient
+}
+
+// NewSliceIterator returns an iterator yielding recipients in order.
+func NewSliceIterator(recipients []MergeRecipient) MergeIterator {
+	return &sliceIterator{recipients: recipients}
+}
+
+// Next returns the next recipient.
+func (i *sliceIterator) Next() (MergeRecipient, bool) {
+	if len(i.recipients) == 0 {
+		return MergeRecipient{}, false
+	}
+	recipient := i.recipients[0]
+	i.recipients = i.recipients[1:]
+	return recipient, true
+}
+
+// Err returns nil, as slices cannot fail.
+func (i *sliceIterator) Err() error {
+	return nil
+}
+
+// MergeResult is the outcome of the email of a recipient.
+type MergeResult struct {
+	Recipient MergeRecipient
+	// Index is the position of the recipient in the iterator, starting at 0.
+	Index int
+	// MessageID is the Message-ID of the email, e.g. to match bounces.
+	MessageID string
+	// Err is the error rendering or sending the email, nil if it was sent.
+	Err error
+}
+
+// MergeReport summarises a mail merge.
+type MergeReport struct {
+	Sent int
+	// Failed holds the results of the recipients whose email could not be sent, in no particular order.
+	Failed []MergeResult
+}
+
+// MergeOptions configures a Merge. Zero values select the defaults.
+type MergeOptions struct {
+	// Concurrency is the number of emails rendered and sent concurrently.
+	Concurrency int
+	// Rate, if set, is the maximum number of emails sent per RatePeriod, which is a second by default,
+	// to stay within the limits of the mail server.
+	Rate       int
+	RatePeriod time.Duration
+	// OnResult, if set, is called with the result of every recipient, e.g. to record the progress
+	// of the merge. Calls are not concurrent.
+	OnResult func(result MergeResult)
+}
+
+// Merge sends an email template of a Registry to many recipients, each email being rendered
+// with the data of its recipient, e.g. for newsletters.
+type Merge struct {
+	transport Transport
+	registry  *Registry
+	template  string
+	base      Message
+	options   MergeOptions
+}
+
+// NewMerge returns pointer to Merge sending the email template of registry through transport.
+// The emails copy the sender, headers and attachments of base and are sent to a single recipient.
+// Their subject is the subject line of the template, or else the subject of base.
+// An SMTPConnection of SMTPTransport.Dial sends the emails over a single connection,
+// one at a time.
+func NewMerge(transport Transport, registry *Registry, template string, base Message, options MergeOptions) (*Merge, error) {
+	if transport == nil {
+		return nil, errors.New(constants.NilTransport)
+	}
+	if registry == nil {
+		return nil, errors.New(constants.NilRegistry)
+	}
+	if _, ok := registry.emails[template]; !ok {
+		return nil, fmt.Errorf("%s %q", constants.UnknownTemplate, template)
+	}
+	if options.Concurrency <= 0 {
+		options.Concurrency = DefaultMergeConcurrency
+	}
+	if options.RatePeriod <= 0 {
+		options.RatePeriod = time.Second
+	}
+	return &Merge{transport: transport, registry: registry, template: template, base: base, options: options}, nil
+}
+
+// Send renders and sends the email of every recipient of recipients, until they are exhausted or ctx is done.
+// A failed recipient does not stop the merge; its result is reported in the MergeReport and to OnResult.
+// The error is the error of the iterator or of ctx, in which case the remaining recipients were not attempted.
+func (m *Merge) Send(ctx context.Context, recipients MergeIterator) (MergeReport, error) {
+	var report MergeReport
+	var mu sync.Mutex
+	var workers sync.WaitGroup
+	limiter := &mergeLimiter{}
+	if m.options.Rate > 0 {
+		limiter.interval = m.options.RatePeriod / time.Duration(m.options.Rate)
+	}
+	jobs := make(chan MergeResult)
+	for i := 0; i < m.options.Concurrency; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for result := range jobs {
+				result.Err = m.send(ctx, limiter, &result)
+				mu.Lock()
+				if result.Err == nil {
+					report.Sent++
+				} else {
+					report.Failed = append(report.Failed, result)
+				}
+				if m.options.OnResult != nil {
+					m.options.OnResult(result)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	err := ctx.Err()
+	for index := 0; err == nil; index++ {
+		recipient, ok := recipients.Next()
+		if !ok {
+			err = recipients.Err()
+			break
+		}
+		select {
+		case jobs <- MergeResult{Recipient: recipient, Index: index}:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+	}
+	close(jobs)
+	workers.Wait()
+	return report, err
+}
+
+// send renders the email of the recipient of result and sends it once the rate limit allows.
+func (m *Merge) send(ctx context.Context, limiter *mergeLimiter, result *MergeResult) error {
+	rendered, err := m.registry.Render(m.template, result.Recipient.Data)
+	if err != nil {
+		return err
+	}
+	message := m.base
+	message.To = []string{result.Recipient.To}
+	message.Cc = nil
+	message.Bcc = nil
+	if rendered.Subject != "" {
+		message.Subject = rendered.Subject
+	}
+	message.ContentType = MIME_HTML
+	message.Body = []byte(rendered.HTML)
+	message.PlainText = []byte(rendered.Text)
+	sender, err := message.Sender()
+	if err != nil {
+		return err
+	}
+	message.MessageID = newMessageID(sender)
+	result.MessageID = message.MessageID
+	if err := limiter.wait(ctx); err != nil {
+		return err
+	}
+	return SendMessage(m.transport, &message)
+}
+
+// mergeLimiter spaces the emails of a merge by interval.
+type mergeLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// wait reserves the next slot and waits for it, or returns the error of ctx.
+func (l *mergeLimiter) wait(ctx context.Context) error {
+	if l.interval <= 0 {
+		return ctx.Err()
+	}
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	at := l.next
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+	timer := time.NewTimer(time.Until(at))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
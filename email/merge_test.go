@@ -0,0 +1,145 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// failingIterator yields recipients and then fails.
+type failingIterator struct {
+	MergeIterator
+}
+
+// Err returns the failure of the iterator.
+func (i *failingIterator) Err() error {
+	return errors.New("cursor closed")
+}
+
+// concurrentTransport records the maximum number of messages sent concurrently.
+type concurrentTransport struct {
+	MemoryTransport
+	mu      sync.Mutex
+	current int
+	max     int
+}
+
+// Send records the message after a delay.
+func (t *concurrentTransport) Send(from string, to []string, message []byte) error {
+	t.mu.Lock()
+	t.current++
+	if t.current > t.max {
+		t.max = t.current
+	}
+	t.mu.Unlock()
+	time.Sleep(10 * time.Millisecond)
+	t.mu.Lock()
+	t.current--
+	t.mu.Unlock()
+	return t.MemoryTransport.Send(from, to, message)
+}
+
+// mergeRecipients returns count recipients named after their position.
+func mergeRecipients(count int) []MergeRecipient {
+	recipients := make([]MergeRecipient, count)
+	for i := range recipients {
+		name := fmt.Sprint("user", i)
+		recipients[i] = MergeRecipient{To: name + "@abc.com", Data: map[string]string{"Name": name}}
+	}
+	return recipients
+}
+
+// TestMerge runs several test cases to check that a mail merge sends a personalised email
+// to every recipient and reports failures.
+func TestMerge(t *testing.T) {
+	registry, err := NewRegistry(testTemplates(), RegistryOptions{Funcs: map[string]interface{}{"upper": strings.ToUpper}})
+	if err != nil {
+		t.Fatal("NewRegistry", err)
+	}
+	invalid := mergeRecipients(5)
+	invalid[3].To = "not an address"
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	tests := []struct {
+		name       string
+		ctx        context.Context
+		recipients MergeIterator
+		options    MergeOptions
+		sent       int
+		failed     []int
+		err        bool
+	}{
+		{"All recipients", context.Background(), NewSliceIterator(mergeRecipients(20)), MergeOptions{}, 20, nil, false},
+		{"Invalid recipient", context.Background(), NewSliceIterator(invalid), MergeOptions{Concurrency: 1}, 4, []int{3}, false},
+		{"Iterator error", context.Background(), &failingIterator{NewSliceIterator(mergeRecipients(2))}, MergeOptions{}, 2, nil, true},
+		{"Cancelled", cancelled, NewSliceIterator(mergeRecipients(3)), MergeOptions{}, 0, nil, true},
+	}
+
+	for _, c := range tests {
+		t.Run(c.name, func(t *testing.T) {
+			transport := NewMemoryTransport()
+			results := 0
+			c.options.OnResult = func(result MergeResult) { results++ }
+			merge, err := NewMerge(transport, registry, "welcome.gohtml", Message{From: "Richa <richa@abc.com>"}, c.options)
+			if err != nil {
+				t.Fatal("NewMerge", c.name, err)
+			}
+			report, err := merge.Send(c.ctx, c.recipients)
+			if (err != nil) != c.err || report.Sent != c.sent || len(report.Failed) != len(c.failed) || results != c.sent+len(c.failed) {
+				t.Fatal("Merge", c.name, report, err, results)
+			}
+			for i, index := range c.failed {
+				if report.Failed[i].Index != index || report.Failed[i].Err == nil {
+					t.Fatal("Merge failure", c.name, report.Failed[i])
+				}
+			}
+			messages := transport.Messages()
+			for _, message := range messages {
+				name := strings.TrimSuffix(message.To[0], "@abc.com")
+				if len(message.To) != 1 || !bytes.Contains(message.Message, []byte("Hi "+strings.ToUpper(name))) ||
+					!bytes.Contains(message.Message, []byte("Subject: Welcome "+name+" & friends")) {
+					t.Fatal("Merge message", c.name, string(message.Message))
+				}
+			}
+			if len(messages) != c.sent {
+				t.Fatal("Merge messages", c.name, len(messages))
+			}
+			fmt.Println("Merge-", c.name, "Pass")
+		})
+	}
+}
+
+// TestMergeLimits checks that a mail merge bounds its concurrency and rate.
+func TestMergeLimits(t *testing.T) {
+	registry, err := NewRegistry(testTemplates(), RegistryOptions{Funcs: map[string]interface{}{"upper": strings.ToUpper}})
+	if err != nil {
+		t.Fatal("NewRegistry", err)
+	}
+	transport := &concurrentTransport{}
+	merge, err := NewMerge(transport, registry, "welcome.gohtml", Message{From: "richa@abc.com"}, MergeOptions{Concurrency: 3})
+	if err != nil {
+		t.Fatal("NewMerge", err)
+	}
+	if report, err := merge.Send(context.Background(), NewSliceIterator(mergeRecipients(12))); err != nil || report.Sent != 12 || transport.max != 3 {
+		t.Fatal("Merge concurrency", report, err, transport.max)
+	}
+	merge, err = NewMerge(NewMemoryTransport(), registry, "welcome.gohtml", Message{From: "richa@abc.com"},
+		MergeOptions{Concurrency: 5, Rate: 20})
+	if err != nil {
+		t.Fatal("NewMerge", err)
+	}
+	start := time.Now()
+	if report, err := merge.Send(context.Background(), NewSliceIterator(mergeRecipients(5))); err != nil || report.Sent != 5 ||
+		time.Since(start) < 200*time.Millisecond {
+		t.Fatal("Merge rate", report, err, time.Since(start))
+	}
+	if _, err := NewMerge(NewMemoryTransport(), registry, "missing.gohtml", Message{}, MergeOptions{}); err == nil {
+		t.Fatal("Merge unknown template")
+	}
+	fmt.Println("Merge limits- Pass")
+}
@@ -0,0 +1,399 @@
+package email
+
+import (
+	"github.com/programmer-richa/utility/constants"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxLineLength is the length after which header lines are folded, as recommended by RFC 5322.
+const maxLineLength = 78
+
+// generatedHeaders are written from the fields of Message and cannot be set as additional headers.
+var generatedHeaders = map[string]bool{
+	"From": true, "To": true, "Cc": true, "Bcc": true, "Reply-To": true, "Subject": true, "Date": true,
+	"Message-Id": true, "Mime-Version": true, "Content-Type": true, "Content-Transfer-Encoding": true,
+}
+
+// Message is an email message, written in RFC 5322 form by WriteTo.
+// Addresses may hold a display name, e.g. "Richa <programmer.richa@gmail.com>";
+// non-ASCII display names and subjects are written as RFC 2047 encoded words.
+type Message struct {
+	From    string
+	To      []string
+	Cc      []string
+	Bcc     []string
+	ReplyTo []string
+	Subject string
+	// Date defaults to the time the message is written.
+	Date time.Time
+	// MessageID defaults to a random id at the domain of From, e.g. <3f2a...@gmail.com>.
+	MessageID string
+	// Header holds additional headers, e.g. List-Unsubscribe.
+	Header textproto.MIMEHeader
+	// ContentType is the type of Body, MIME_TEXT by default.
+	ContentType string
+	Body        []byte
+	// PlainText is the plain text version of an HTML Body, see HTMLToText.
+	// If it is set, Body and PlainText are sent as a multipart/alternative.
+	PlainText []byte
+	// Attachments are sent as files to download.
+	Attachments []Attachment
+	// Inline attachments, such as a logo, are displayed by the HTML Body, which references
+	// them as cid:ContentID. Their ContentID defaults to a new id, see NewContentID, so it must
+	// be set for the Body to reference them unless they are added by Embed.
+	Inline []Attachment
+}
+
+// NewMessage returns pointer to Message
+func NewMessage(from string, to []string, subject string) *Message {
+	return &Message{From: from, To: to, Subject: subject, Header: textproto.MIMEHeader{}}
+}
+
+// SetHeader sets the additional header name to value, replacing its previous values.
+func (m *Message) SetHeader(name string, value string) {
+	if m.Header == nil {
+		m.Header = textproto.MIMEHeader{}
+	}
+	m.Header.Set(name, value)
+}
+
+// Sender returns the address of From, to be used as envelope sender.
+func (m *Message) Sender() (string, error) {
+	address, err := m.fromAddress()
+	if err != nil {
+		return "", err
+	}
+	return address.Address, nil
+}
+
+// fromAddress parses From, which is required.
+func (m *Message) fromAddress() (*mail.Address, error) {
+	if strings.TrimSpace(m.From) == "" {
+		return nil, errors.New(constants.NoSender)
+	}
+	return parseAddress(m.From)
+}
+
+// Recipients returns the addresses of To, Cc and Bcc, to be used as envelope recipients.
+func (m *Message) Recipients() ([]string, error) {
+	var recipients []string
+	for _, list := range [][]string{m.To, m.Cc, m.Bcc} {
+		for _, value := range list {
+			address, err := parseAddress(value)
+			if err != nil {
+				return nil, err
+			}
+			recipients = append(recipients, address.Address)
+		}
+	}
+	if len(recipients) == 0 {
+		return nil, errors.New(constants.NoRecipients)
+	}
+	return recipients, nil
+}
+
+// Bytes returns the message in RFC 5322 form.
+func (m *Message) Bytes() ([]byte, error) {
+	buffer := new(bytes.Buffer)
+	if _, err := m.WriteTo(buffer); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// WriteTo writes the message in RFC 5322 form to w.
+// Bcc recipients are not written. Header lines end with CRLF and are folded,
+// and the bodies are quoted-printable or base64 encoded, so the message is valid 7-bit SMTP data.
+func (m *Message) WriteTo(w io.Writer) (int64, error) {
+	header := new(headerWriter)
+	from, err := m.fromAddress()
+	if err != nil {
+		return 0, err
+	}
+	header.write("From", from.String())
+	for _, field := range []struct {
+		name      string
+		addresses []string
+	}{{"To", m.To}, {"Cc", m.Cc}, {"Reply-To", m.ReplyTo}} {
+		if len(field.addresses) == 0 {
+			continue
+		}
+		list, err := formatAddressList(field.addresses)
+		if err != nil {
+			return 0, err
+		}
+		header.write(field.name, list)
+	}
+	header.write("Subject", mime.QEncoding.Encode("UTF-8", m.Subject))
+	date := m.Date
+	if date.IsZero() {
+		date = time.Now()
+	}
+	header.write("Date", date.Format(time.RFC1123Z))
+	messageID := m.MessageID
+	if messageID == "" {
+		messageID = newMessageID(from.Address)
+	}
+	header.write("Message-ID", messageID)
+	header.write("MIME-Version", "1.0")
+	if err := header.writeCustom(m.Header); err != nil {
+		return 0, err
+	}
+	content, err := m.content()
+	if err != nil {
+		return 0, err
+	}
+	for _, name := range sortedKeys(content.header) {
+		header.write(name, content.header.Get(name))
+	}
+	header.buffer.WriteString("\r\n")
+	if err := content.body(&header.buffer); err != nil {
+		return 0, err
+	}
+	return header.buffer.WriteTo(w)
+}
+
+// Attach adds the attachment named filename holding the content of r, see NewAttachment.
+func (m *Message) Attach(filename string, r io.Reader) error {
+	attachment, err := NewAttachment(filename, r)
+	if err != nil {
+		return err
+	}
+	m.Attachments = append(m.Attachments, attachment)
+	return nil
+}
+
+// AttachFile adds the attachment holding the file at path, see NewFileAttachment.
+func (m *Message) AttachFile(path string) error {
+	attachment, err := NewFileAttachment(path)
+	if err != nil {
+		return err
+	}
+	m.Attachments = append(m.Attachments, attachment)
+	return nil
+}
+
+// Embed adds the inline attachment named filename holding the content of r
+// and returns its new content id, which the HTML Body references as cid:<id>.
+func (m *Message) Embed(filename string, r io.Reader) (string, error) {
+	attachment, err := NewAttachment(filename, r)
+	if err != nil {
+		return "", err
+	}
+	if attachment, err = attachment.inline(); err != nil {
+		return "", err
+	}
+	m.Inline = append(m.Inline, attachment)
+	return attachment.ContentID, nil
+}
+
+// EmbedFile adds the inline attachment holding the file at path
+// and returns its new content id, which the HTML Body references as cid:<id>.
+func (m *Message) EmbedFile(path string) (string, error) {
+	attachment, err := NewFileAttachment(path)
+	if err != nil {
+		return "", err
+	}
+	if attachment, err = attachment.inline(); err != nil {
+		return "", err
+	}
+	m.Inline = append(m.Inline, attachment)
+	return attachment.ContentID, nil
+}
+
+// content returns the MIME entity holding the body and attachments of the message.
+// Its structure is multipart/mixed(multipart/related(multipart/alternative(text, html), inline...), attachments...),
+// omitting the multiparts that would hold a single entity.
+// It returns an error if the content id of an inline attachment is invalid.
+func (m *Message) content() (*entity, error) {
+	contentType := m.ContentType
+	if contentType == "" {
+		contentType = MIME_TEXT
+	}
+	content := textEntity(contentType, m.Body)
+	if len(m.PlainText) > 0 {
+		// Parts of an alternative are written in increasing order of preference.
+		content = multipartEntity("alternative", textEntity(MIME_TEXT, m.PlainText), content)
+	}
+	if len(m.Inline) > 0 {
+		parts := []*entity{content}
+		for _, attachment := range m.Inline {
+			attachment, err := attachment.inline()
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, attachment.entity("inline"))
+		}
+		content = multipartEntity("related", parts...)
+	}
+	if len(m.Attachments) > 0 {
+		parts := []*entity{content}
+		for _, attachment := range m.Attachments {
+			parts = append(parts, attachment.entity("attachment"))
+		}
+		content = multipartEntity("mixed", parts...)
+	}
+	return content, nil
+}
+
+// entity is a MIME entity: its headers and the function writing its body.
+type entity struct {
+	header textproto.MIMEHeader
+	body   func(w io.Writer) error
+}
+
+// textEntity returns an entity of content type holding text, quoted-printable encoded.
+func textEntity(contentType string, text []byte) *entity {
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", contentType)
+	header.Set("Content-Transfer-Encoding", "quoted-printable")
+	return &entity{header: header, body: func(w io.Writer) error {
+		encoder := quotedprintable.NewWriter(w)
+		if _, err := encoder.Write(text); err != nil {
+			return err
+		}
+		if err := encoder.Close(); err != nil {
+			return err
+		}
+		if len(text) > 0 && text[len(text)-1] != '\n' {
+			_, err := io.WriteString(w, "\r\n")
+			return err
+		}
+		return nil
+	}}
+}
+
+// multipartEntity returns a multipart entity of subtype, e.g. alternative, holding parts.
+func multipartEntity(subtype string, parts ...*entity) *entity {
+	// The boundary is shorter than the default one of multipart.Writer so that nested
+	// Content-Type headers, which multipart.Writer does not fold, fit on a line.
+	random := make([]byte, 15)
+	rand.Read(random)
+	boundary := hex.EncodeToString(random)
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", mime.FormatMediaType("multipart/"+subtype, map[string]string{"boundary": boundary}))
+	return &entity{header: header, body: func(w io.Writer) error {
+		writer := multipart.NewWriter(w)
+		if err := writer.SetBoundary(boundary); err != nil {
+			return err
+		}
+		for _, part := range parts {
+			partWriter, err := writer.CreatePart(part.header)
+			if err != nil {
+				return err
+			}
+			if err := part.body(partWriter); err != nil {
+				return err
+			}
+		}
+		return writer.Close()
+	}}
+}
+
+// sortedKeys returns the names of header in alphabetical order.
+func sortedKeys(header textproto.MIMEHeader) []string {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// headerWriter writes folded header lines.
+type headerWriter struct {
+	buffer bytes.Buffer
+}
+
+// write writes the header line name: value, folding it at spaces to keep lines short.
+func (h *headerWriter) write(name string, value string) {
+	line := name + ":"
+	for _, word := range strings.Split(value, " ") {
+		if len(line)+1+len(word) > maxLineLength && len(line) > len(name)+1 {
+			h.buffer.WriteString(line + "\r\n")
+			line = ""
+		}
+		line += " " + word
+	}
+	h.buffer.WriteString(line + "\r\n")
+}
+
+// writeCustom writes the additional headers sorted by name.
+// Generated headers and values holding line breaks, which would inject headers, are rejected.
+func (h *headerWriter) writeCustom(header textproto.MIMEHeader) error {
+	for _, name := range sortedKeys(header) {
+		if strings.ContainsAny(name, "\r\n: ") || generatedHeaders[textproto.CanonicalMIMEHeaderKey(name)] {
+			return fmt.Errorf("%s %q", constants.InvalidMailHeader, name)
+		}
+		for _, value := range header[name] {
+			if strings.ContainsAny(value, "\r\n") {
+				return fmt.Errorf("%s %q", constants.InvalidMailHeader, name)
+			}
+			h.write(name, mime.QEncoding.Encode("UTF-8", value))
+		}
+	}
+	return nil
+}
+
+// parseAddress parses a single RFC 5322 address.
+func parseAddress(value string) (*mail.Address, error) {
+	address, err := mail.ParseAddress(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s %q", constants.InvalidMailAddress, value)
+	}
+	return address, nil
+}
+
+// formatAddressList returns the addresses formatted for an address list header.
+func formatAddressList(values []string) (string, error) {
+	formatted := make([]string, 0, len(values))
+	for _, value := range values {
+		address, err := parseAddress(value)
+		if err != nil {
+			return "", err
+		}
+		formatted = append(formatted, address.String())
+	}
+	return strings.Join(formatted, ", "), nil
+}
+
+// newMessageID returns a unique Message-ID at the domain of address.
+func newMessageID(address string) string {
+	random := make([]byte, 16)
+	rand.Read(random)
+	domain := "localhost"
+	if at := strings.LastIndex(address, "@"); at >= 0 {
+		domain = address[at+1:]
+	}
+	return fmt.Sprintf("<%d.%s@%s>", time.Now().UnixNano(), hex.EncodeToString(random), domain)
+}
+
+// SendMessage delivers message through transport to its To, Cc and Bcc recipients.
+func SendMessage(transport Transport, message *Message) error {
+	sender, err := message.Sender()
+	if err != nil {
+		return err
+	}
+	recipients, err := message.Recipients()
+	if err != nil {
+		return err
+	}
+	data, err := message.Bytes()
+	if err != nil {
+		return err
+	}
+	return transport.Send(sender, recipients, data)
+}
@@ -0,0 +1,138 @@
+package email
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestMessage runs several test cases to check the correctness of
+// the RFC 5322 messages written by Message.
+func TestMessage(t *testing.T) {
+	date := time.Date(2020, 6, 1, 10, 0, 0, 0, time.UTC)
+	many := make([]string, 10)
+	for i := range many {
+		many[i] = fmt.Sprintf("receiver%d@abc.com", i)
+	}
+	tests := []struct {
+		name    string
+		message *Message
+		headers map[string]string
+		body    string
+		valid   bool
+	}{
+		{
+			"Plain message",
+			&Message{From: "Richa <richa@abc.com>", To: []string{"a@abc.com", "b@abc.com"}, Subject: "Hello",
+				Date: date, MessageID: "<1@abc.com>", Body: []byte("Hi\nthere")},
+			map[string]string{
+				"From":         `"Richa" <richa@abc.com>`,
+				"To":           "<a@abc.com>, <b@abc.com>",
+				"Subject":      "Hello",
+				"Date":         "Mon, 01 Jun 2020 10:00:00 +0000",
+				"Message-Id":   "<1@abc.com>",
+				"Content-Type": MIME_TEXT,
+			},
+			"Hi\r\nthere\r\n",
+			true,
+		}, {
+			"Copies and custom headers",
+			&Message{From: "richa@abc.com", To: []string{"a@abc.com"}, Cc: []string{"c@abc.com"}, Bcc: []string{"hidden@abc.com"},
+				ReplyTo: []string{"support@abc.com"}, ContentType: MIME_HTML, Body: []byte("<p>Hi</p>"),
+				Header: map[string][]string{"List-Unsubscribe": {"<https://abc.com/unsubscribe>"}}},
+			map[string]string{
+				"Cc":               "<c@abc.com>",
+				"Bcc":              "",
+				"Reply-To":         "<support@abc.com>",
+				"List-Unsubscribe": "<https://abc.com/unsubscribe>",
+				"Content-Type":     MIME_HTML,
+			},
+			"<p>Hi</p>\r\n",
+			true,
+		}, {
+			"Non-ASCII subject and name",
+			&Message{From: "Ríchä <richa@abc.com>", To: many, Subject: "Vérifiez votre compte ✓"},
+			map[string]string{
+				"Subject": "Vérifiez votre compte ✓",
+				"To":      "<" + strings.Join(many, ">, <") + ">",
+			},
+			"",
+			true,
+		}, {
+			"Invalid address",
+			&Message{From: "richa@abc.com", To: []string{"not an address"}},
+			nil,
+			"",
+			false,
+		}, {
+			"Header injection",
+			&Message{From: "richa@abc.com", To: []string{"a@abc.com"}, Header: map[string][]string{"X-Note": {"a\r\nBcc: x@abc.com"}}},
+			nil,
+			"",
+			false,
+		}, {
+			"Generated header",
+			&Message{From: "richa@abc.com", To: []string{"a@abc.com"}, Header: map[string][]string{"Subject": {"Again"}}},
+			nil,
+			"",
+			false,
+		},
+	}
+
+	decoder := new(mime.WordDecoder)
+	for _, c := range tests {
+		t.Run(c.name, func(t *testing.T) {
+			data, err := c.message.Bytes()
+			if (err == nil) != c.valid {
+				t.Fatal("Message", c.name, err)
+			}
+			if err != nil {
+				fmt.Println("Message-", c.name, "Pass")
+				return
+			}
+			for _, line := range strings.SplitAfter(string(data), "\n") {
+				if !strings.HasSuffix(line, "\r\n") && line != "" || len(line) > 2+maxLineLength {
+					t.Fatal("Message line", c.name, line)
+				}
+			}
+			parsed, err := mail.ReadMessage(bytes.NewReader(data))
+			if err != nil {
+				t.Fatal("ReadMessage", c.name, err)
+			}
+			for name, expected := range c.headers {
+				if value, _ := decoder.DecodeHeader(parsed.Header.Get(name)); value != expected {
+					t.Fatal("Message header", c.name, name, value)
+				}
+			}
+			if _, err := mail.ParseAddress(parsed.Header.Get("From")); err != nil || parsed.Header.Get("Message-Id") == "" {
+				t.Fatal("Message identity", c.name, err)
+			}
+			body, _ := ioutil.ReadAll(parsed.Body)
+			if string(body) != c.body {
+				t.Fatal("Message body", c.name, string(body))
+			}
+			fmt.Println("Message-", c.name, "Pass")
+		})
+	}
+}
+
+// TestSendMessage checks that Bcc receivers are envelope recipients.
+func TestSendMessage(t *testing.T) {
+	transport := NewMemoryTransport()
+	message := NewMessage("Richa <richa@abc.com>", []string{"a@abc.com"}, "Hello")
+	message.Bcc = []string{"Hidden <hidden@abc.com>"}
+	if err := SendMessage(transport, message); err != nil {
+		t.Fatal("SendMessage", err)
+	}
+	sent := transport.Messages()
+	if len(sent) != 1 || sent[0].From != "richa@abc.com" || strings.Join(sent[0].To, ",") != "a@abc.com,hidden@abc.com" ||
+		bytes.Contains(sent[0].Message, []byte("hidden@abc.com")) {
+		t.Fatal("SendMessage", sent)
+	}
+	fmt.Println("SendMessage- Pass")
+}
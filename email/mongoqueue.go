@@ -0,0 +1,197 @@
+package email
+
+import (
+	"github.com/programmer-richa/utility/constants"
+	"github.com/programmer-richa/utility/database"
+	"context"
+	"errors"
+	"time"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MailQueueCollection is the default collection of a MongoQueueStore.
+const MailQueueCollection = "_mail_queue"
+
+// DefaultMailLease is how long a MongoQueueStore reserves mail for its queue unless set in MongoQueueStore.Lease.
+const DefaultMailLease = 10 * time.Minute
+
+// MongoQueueStore persists the mail of a Queue in a collection, and its dead letters
+// in the same collection suffixed with "_dead".
+// The queues of several replicas may share the collection: every store claims the mail its queue
+// delivers, so that the mail is delivered by a single queue.
+type MongoQueueStore struct {
+	helper         *database.MongoHelper
+	collection     string
+	deadCollection string
+	owner          string
+	// Lease is how long the mail claimed by the store is reserved for its queue, DefaultMailLease if it is 0.
+	// Mail is claimed when it is added or returned by Pending, and the claim of mail waiting for a retry
+	// is extended past its next attempt. Other stores only return mail whose claim expired,
+	// e.g. after a crash, so Lease must exceed the time mail waits in a busy queue.
+	Lease time.Duration
+}
+
+// queuedMailDocument is the stored form of QueuedMail.
+type queuedMailDocument struct {
+	ID         primitive.ObjectID `bson:"_id"`
+	QueuedMail `bson:",inline"`
+	// Owner is the store whose queue delivers the mail until LockedUntil.
+	Owner       string    `bson:"owner,omitempty"`
+	LockedUntil time.Time `bson:"lockedUntil,omitempty"`
+	// Version is incremented by every claim, so that concurrent claims of the mail succeed once.
+	Version int64 `bson:"version"`
+}
+
+// NewMongoQueueStore returns pointer to MongoQueueStore storing mail in collectionName,
+// or MailQueueCollection if it is empty. Every store is a distinct owner of the mail it claims.
+func NewMongoQueueStore(helper *database.MongoHelper, collectionName string) (*MongoQueueStore, error) {
+	if helper == nil {
+		return nil, errors.New(constants.NilMongoHelper)
+	}
+	if collectionName == "" {
+		collectionName = MailQueueCollection
+	}
+	return &MongoQueueStore{
+		helper:         helper,
+		collection:     collectionName,
+		deadCollection: collectionName + "_dead",
+		owner:          uuid.New().String(),
+	}, nil
+}
+
+// Add stores new mail, claimed by the store.
+func (s *MongoQueueStore) Add(mail QueuedMail) error {
+	return s.insert(s.collection, mail, time.Now().Add(s.lease()))
+}
+
+// Update stores the attempts of mail waiting for a retry and extends its claim past the retry.
+// It returns ErrMailClaimLost, leaving the mail unchanged, unless the store still holds the claim of the mail.
+func (s *MongoQueueStore) Update(mail QueuedMail) error {
+	id, err := primitive.ObjectIDFromHex(mail.ID)
+	if err != nil {
+		return err
+	}
+	client, err := s.helper.GetSession()
+	if err != nil {
+		return err
+	}
+	// Close DB connection after this method is executed.
+	defer client.Disconnect(context.TODO())
+	collection, err := s.helper.GetCollection(client, s.collection)
+	if err != nil {
+		return err
+	}
+	update := database.NewUpdate().
+		Set("attempts", mail.Attempts).
+		Set("nextAttempt", mail.NextAttempt).
+		Set("lastError", mail.LastError).
+		Set("lockedUntil", mail.NextAttempt.Add(s.lease())).
+		Inc(database.VersionField, 1)
+	result, err := collection.UpdateOne(context.TODO(),
+		bson.M{"_id": id, "owner": s.owner, "lockedUntil": bson.M{"$gte": time.Now()}},
+		update.Document())
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrMailClaimLost
+	}
+	return nil
+}
+
+// Remove forgets delivered mail.
+func (s *MongoQueueStore) Remove(id string) error {
+	return s.helper.DeleteDocument(s.collection, id, nil)
+}
+
+// Pending claims and returns the stored mail that is claimed by the store, or by no store
+// or whose claim expired, oldest first. Mail claimed by another store meanwhile is skipped.
+func (s *MongoQueueStore) Pending() ([]QueuedMail, error) {
+	now := time.Now()
+	documents, err := s.list(s.collection, bson.M{"$or": bson.A{
+		bson.M{"owner": s.owner},
+		bson.M{"lockedUntil": bson.M{"$not": bson.M{"$gte": now}}},
+	}})
+	if err != nil {
+		return nil, err
+	}
+	mails := make([]QueuedMail, 0, len(documents))
+	for _, document := range documents {
+		lockedUntil := now.Add(s.lease())
+		if document.NextAttempt.After(now) {
+			lockedUntil = document.NextAttempt.Add(s.lease())
+		}
+		claim := database.NewUpdate().Set("owner", s.owner).Set("lockedUntil", lockedUntil)
+		_, err := s.helper.ApplyVersionedUpdate(context.TODO(), s.collection, document.ID.Hex(), document.Version, claim)
+		if errors.Is(err, database.ErrConflict) || errors.Is(err, database.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		mails = append(mails, document.mail())
+	}
+	return mails, nil
+}
+
+// DeadLetter moves mail to the dead letters.
+func (s *MongoQueueStore) DeadLetter(mail QueuedMail) error {
+	if err := s.insert(s.deadCollection, mail, time.Time{}); err != nil && !errors.Is(err, database.ErrDuplicateKey) {
+		return err
+	}
+	return s.Remove(mail.ID)
+}
+
+// DeadLetters returns the mail whose delivery failed MaxAttempts times, oldest first.
+func (s *MongoQueueStore) DeadLetters() ([]QueuedMail, error) {
+	documents, err := s.list(s.deadCollection, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	mails := make([]QueuedMail, 0, len(documents))
+	for _, document := range documents {
+		mails = append(mails, document.mail())
+	}
+	return mails, nil
+}
+
+// lease returns the duration of the claims of the store.
+func (s *MongoQueueStore) lease() time.Duration {
+	if s.Lease <= 0 {
+		return DefaultMailLease
+	}
+	return s.Lease
+}
+
+// insert stores mail in the collection, claimed by the store until lockedUntil unless it is zero.
+func (s *MongoQueueStore) insert(collectionName string, mail QueuedMail, lockedUntil time.Time) error {
+	id, err := primitive.ObjectIDFromHex(mail.ID)
+	if err != nil {
+		return err
+	}
+	document := queuedMailDocument{ID: id, QueuedMail: mail}
+	if !lockedUntil.IsZero() {
+		document.Owner, document.LockedUntil = s.owner, lockedUntil
+	}
+	_, err = s.helper.InsertDocument(collectionName, document)
+	return err
+}
+
+// list returns the documents of the collection matching filter, oldest first.
+func (s *MongoQueueStore) list(collectionName string, filter bson.M) ([]queuedMailDocument, error) {
+	documents := []queuedMailDocument{}
+	aggregation := database.NewAggregation().Match(filter).Sort(bson.D{{Key: "createdAt", Value: 1}})
+	if err := s.helper.Aggregate(context.TODO(), collectionName, aggregation, &documents); err != nil {
+		return nil, err
+	}
+	return documents, nil
+}
+
+// mail returns the QueuedMail of the document.
+func (d queuedMailDocument) mail() QueuedMail {
+	mail := d.QueuedMail
+	mail.ID = d.ID.Hex()
+	return mail
+}
@@ -0,0 +1,429 @@
+package email
+
+import (
+	"github.com/programmer-richa/utility/constants"
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Default options of a Queue.
+const (
+	DefaultQueueWorkers = 4
+	DefaultMaxAttempts  = 5
+	DefaultBackoff      = 30 * time.Second
+	DefaultMaxBackoff   = time.Hour
+	DefaultPollInterval = time.Minute
+)
+
+// QueuedMail is a message waiting in a Queue for delivery.
+type QueuedMail struct {
+	ID      string   `bson:"-"`
+	From    string   `bson:"from"`
+	To      []string `bson:"to"`
+	Message []byte   `bson:"message"`
+	// Attempts counts the failed deliveries of the message.
+	Attempts int `bson:"attempts"`
+	// NextAttempt is the time of the next delivery of a message waiting for a retry.
+	NextAttempt time.Time `bson:"nextAttempt"`
+	LastError   string    `bson:"lastError,omitempty"`
+	CreatedAt   time.Time `bson:"createdAt"`
+}
+
+// ErrMailClaimLost reports that a store shared by several queues no longer reserves mail for the queue,
+// e.g. because another queue claimed the mail after the claim of the queue expired.
+var ErrMailClaimLost = errors.New(constants.MailClaimLost)
+
+// QueueStore persists the mail of a Queue, so that it survives restarts.
+// Its methods are called by the workers of the queue, so they must be safe for concurrent use.
+type QueueStore interface {
+	// Add stores new mail.
+	Add(mail QueuedMail) error
+	// Update stores the attempts of mail waiting for a retry.
+	// Stores shared by several queues return ErrMailClaimLost if another queue delivers the mail.
+	Update(mail QueuedMail) error
+	// Remove forgets delivered mail.
+	Remove(id string) error
+	// Pending returns the stored mail, loaded when a queue starts and every PollInterval.
+	// Stores shared by several queues only return the mail that no other queue is delivering.
+	Pending() ([]QueuedMail, error)
+	// DeadLetter moves mail whose delivery failed MaxAttempts times to the dead letters.
+	DeadLetter(mail QueuedMail) error
+}
+
+// QueueOptions configures a Queue. Zero values select the defaults.
+type QueueOptions struct {
+	// Workers is the number of messages delivered concurrently.
+	Workers int
+	// MaxAttempts is the number of failed deliveries after which a message is dead-lettered.
+	MaxAttempts int
+	// Backoff is the delay before the first retry, doubled by every failed retry up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// Store persists the queue. A MemoryQueueStore is used by default, so mail is lost on restart.
+	Store QueueStore
+	// PollInterval is how often a started queue loads the stored mail it is not delivering,
+	// e.g. mail left by a crashed queue sharing the store once its claim expired.
+	PollInterval time.Duration
+	// OnError, if set, is called when a delivery fails or the store reports an error.
+	// The mail is zero if the error concerns no message, e.g. a failure to load the stored mail.
+	OnError func(mail QueuedMail, err error)
+}
+
+// Queue delivers mail in the background through a transport, so that HTTP handlers
+// do not wait for the mail server. Failed deliveries are retried with exponential backoff
+// and dead-lettered after MaxAttempts failures.
+// Queue is a Transport itself, e.g. to make MailRequest.Send asynchronous with SetTransport.
+type Queue struct {
+	transport Transport
+	options   QueueOptions
+	// starting is held by Start and load while they load the stored mail, and by Send and deliver
+	// while they store mail, so that mail is either loaded or scheduled by Send, never both,
+	// and delivered mail is not loaded again.
+	starting sync.RWMutex
+	mu       sync.Mutex
+	// cond signals workers that mail is ready or the queue is shut down.
+	cond    *sync.Cond
+	ready   []*QueuedMail
+	retries map[string]*time.Timer
+	// queued holds the ids of the mail the queue delivers, ready, being delivered or waiting for a retry.
+	queued  map[string]bool
+	started bool
+	closed  bool
+	// stop is closed by Shutdown to stop polling the store.
+	stop    chan struct{}
+	workers sync.WaitGroup
+}
+
+// NewQueue returns pointer to Queue delivering mail through transport.
+// Mail is accepted at once, but only delivered once the queue is started.
+func NewQueue(transport Transport, options QueueOptions) (*Queue, error) {
+	if transport == nil {
+		return nil, errors.New(constants.NilTransport)
+	}
+	if options.Workers <= 0 {
+		options.Workers = DefaultQueueWorkers
+	}
+	if options.MaxAttempts <= 0 {
+		options.MaxAttempts = DefaultMaxAttempts
+	}
+	if options.Backoff <= 0 {
+		options.Backoff = DefaultBackoff
+	}
+	if options.MaxBackoff <= 0 {
+		options.MaxBackoff = DefaultMaxBackoff
+	}
+	if options.PollInterval <= 0 {
+		options.PollInterval = DefaultPollInterval
+	}
+	if options.Store == nil {
+		options.Store = NewMemoryQueueStore()
+	}
+	q := &Queue{
+		transport: transport,
+		options:   options,
+		retries:   map[string]*time.Timer{},
+		queued:    map[string]bool{},
+		stop:      make(chan struct{}),
+	}
+	q.cond = sync.NewCond(&q.mu)
+	return q, nil
+}
+
+// Start loads the mail of the store, including the mail sent before, and starts the workers.
+// The store is polled every PollInterval for mail the queue is not delivering until the queue is shut down.
+func (q *Queue) Start() error {
+	q.starting.Lock()
+	defer q.starting.Unlock()
+	q.mu.Lock()
+	closed, started := q.closed, q.started
+	q.mu.Unlock()
+	if closed {
+		return errors.New(constants.QueueClosed)
+	}
+	if started {
+		return nil
+	}
+	pending, err := q.options.Store.Pending()
+	if err != nil {
+		return err
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return errors.New(constants.QueueClosed)
+	}
+	q.started = true
+	for i := range pending {
+		q.add(&pending[i])
+	}
+	for i := 0; i < q.options.Workers; i++ {
+		q.workers.Add(1)
+		go q.work()
+	}
+	go q.poll()
+	return nil
+}
+
+// Send queues message for delivery to the envelope recipients to.
+// It returns an error if the store rejects the message or the queue is shut down.
+func (q *Queue) Send(from string, to []string, message []byte) error {
+	if len(to) == 0 {
+		return errors.New(constants.NoRecipients)
+	}
+	mail := &QueuedMail{
+		ID:        primitive.NewObjectID().Hex(),
+		From:      from,
+		To:        append([]string(nil), to...),
+		Message:   append([]byte(nil), message...),
+		CreatedAt: time.Now(),
+	}
+	q.starting.RLock()
+	defer q.starting.RUnlock()
+	q.mu.Lock()
+	closed := q.closed
+	q.mu.Unlock()
+	if closed {
+		return errors.New(constants.QueueClosed)
+	}
+	if err := q.options.Store.Add(*mail); err != nil {
+		return err
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	// Mail sent before the queue is started is loaded from the store by Start.
+	if q.started {
+		q.add(mail)
+	}
+	return nil
+}
+
+// Enqueue queues message for delivery to its To, Cc and Bcc recipients.
+func (q *Queue) Enqueue(message *Message) error {
+	return SendMessage(q, message)
+}
+
+// Shutdown stops accepting mail and waits for the workers to deliver the mail that is ready,
+// including the messages being delivered, until ctx is done.
+// Mail waiting for a retry is left in the store, to be delivered by the next queue started with it.
+func (q *Queue) Shutdown(ctx context.Context) error {
+	q.mu.Lock()
+	if !q.closed {
+		close(q.stop)
+	}
+	q.closed = true
+	for id, timer := range q.retries {
+		timer.Stop()
+		delete(q.retries, id)
+	}
+	q.cond.Broadcast()
+	q.mu.Unlock()
+	done := make(chan struct{})
+	go func() {
+		q.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// poll loads the stored mail every PollInterval until the queue is shut down.
+func (q *Queue) poll() {
+	ticker := time.NewTicker(q.options.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-q.stop:
+			return
+		case <-ticker.C:
+			q.load()
+		}
+	}
+}
+
+// load schedules the stored mail the queue is not delivering.
+func (q *Queue) load() {
+	q.starting.Lock()
+	defer q.starting.Unlock()
+	pending, err := q.options.Store.Pending()
+	if err != nil {
+		q.report(&QueuedMail{}, err)
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i := range pending {
+		q.add(&pending[i])
+	}
+}
+
+// add schedules mail unless the queue delivers it already. It must be called with q.mu held.
+func (q *Queue) add(mail *QueuedMail) {
+	if q.closed || q.queued[mail.ID] {
+		return
+	}
+	q.queued[mail.ID] = true
+	q.schedule(mail)
+}
+
+// schedule makes mail ready at its next attempt. It must be called with q.mu held.
+func (q *Queue) schedule(mail *QueuedMail) {
+	if q.closed {
+		return
+	}
+	delay := time.Until(mail.NextAttempt)
+	if delay <= 0 {
+		q.ready = append(q.ready, mail)
+		q.cond.Signal()
+		return
+	}
+	q.retries[mail.ID] = time.AfterFunc(delay, func() {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		delete(q.retries, mail.ID)
+		mail.NextAttempt = time.Time{}
+		q.schedule(mail)
+	})
+}
+
+// work delivers ready mail until the queue is shut down and no mail is ready.
+func (q *Queue) work() {
+	defer q.workers.Done()
+	for {
+		q.mu.Lock()
+		for len(q.ready) == 0 && !q.closed {
+			q.cond.Wait()
+		}
+		if len(q.ready) == 0 {
+			q.mu.Unlock()
+			return
+		}
+		mail := q.ready[0]
+		q.ready = q.ready[1:]
+		q.mu.Unlock()
+		q.deliver(mail)
+	}
+}
+
+// deliver sends mail and records the outcome in the store.
+// The queue forgets mail once it is delivered, dead-lettered or claimed by another queue.
+func (q *Queue) deliver(mail *QueuedMail) {
+	err := q.transport.Send(mail.From, mail.To, mail.Message)
+	delivered := err == nil
+	if !delivered {
+		mail.Attempts++
+		mail.LastError = err.Error()
+		q.report(mail, err)
+	}
+	q.starting.RLock()
+	defer q.starting.RUnlock()
+	switch {
+	case delivered:
+		err = q.options.Store.Remove(mail.ID)
+	case mail.Attempts >= q.options.MaxAttempts:
+		err = q.options.Store.DeadLetter(*mail)
+	default:
+		mail.NextAttempt = time.Now().Add(q.backoff(mail.Attempts))
+		err = q.options.Store.Update(*mail)
+		q.report(mail, err)
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		if errors.Is(err, ErrMailClaimLost) {
+			delete(q.queued, mail.ID)
+			return
+		}
+		q.schedule(mail)
+		return
+	}
+	q.report(mail, err)
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.queued, mail.ID)
+}
+
+// backoff returns the delay before the retry following the given number of failed attempts.
+func (q *Queue) backoff(attempts int) time.Duration {
+	delay := q.options.Backoff
+	for i := 1; i < attempts && delay < q.options.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > q.options.MaxBackoff {
+		delay = q.options.MaxBackoff
+	}
+	return delay
+}
+
+// report passes err, if any, to the OnError option.
+func (q *Queue) report(mail *QueuedMail, err error) {
+	if err != nil && q.options.OnError != nil {
+		q.options.OnError(*mail, err)
+	}
+}
+
+// MemoryQueueStore keeps the mail of a Queue in memory. It is safe for concurrent use.
+type MemoryQueueStore struct {
+	mu      sync.Mutex
+	pending map[string]QueuedMail
+	dead    []QueuedMail
+}
+
+// NewMemoryQueueStore returns pointer to MemoryQueueStore
+func NewMemoryQueueStore() *MemoryQueueStore {
+	return &MemoryQueueStore{pending: map[string]QueuedMail{}}
+}
+
+// Add stores new mail.
+func (s *MemoryQueueStore) Add(mail QueuedMail) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pending[mail.ID] = mail
+	return nil
+}
+
+// Update stores the attempts of mail waiting for a retry.
+func (s *MemoryQueueStore) Update(mail QueuedMail) error {
+	return s.Add(mail)
+}
+
+// Remove forgets delivered mail.
+func (s *MemoryQueueStore) Remove(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.pending, id)
+	return nil
+}
+
+// Pending returns the stored mail, oldest first.
+func (s *MemoryQueueStore) Pending() ([]QueuedMail, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pending := make([]QueuedMail, 0, len(s.pending))
+	for _, mail := range s.pending {
+		pending = append(pending, mail)
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].CreatedAt.Before(pending[j].CreatedAt) })
+	return pending, nil
+}
+
+// DeadLetter moves mail to the dead letters.
+func (s *MemoryQueueStore) DeadLetter(mail QueuedMail) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.pending, mail.ID)
+	s.dead = append(s.dead, mail)
+	return nil
+}
+
+// DeadLetters returns the mail whose delivery failed MaxAttempts times.
+func (s *MemoryQueueStore) DeadLetters() []QueuedMail {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]QueuedMail(nil), s.dead...)
+}
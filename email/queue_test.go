@@ -0,0 +1,286 @@
+package email
+
+import (
+	"github.com/programmer-richa/utility/constants"
+	"github.com/programmer-richa/utility/database"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flakyTransport fails the first deliveries of every message.
+type flakyTransport struct {
+	MemoryTransport
+	failures int
+	attempts map[string]int
+	delay    time.Duration
+}
+
+// Send fails until the message was attempted failures times.
+func (t *flakyTransport) Send(from string, to []string, message []byte) error {
+	time.Sleep(t.delay)
+	t.mu.Lock()
+	t.attempts[string(message)]++
+	attempt := t.attempts[string(message)]
+	t.mu.Unlock()
+	if attempt <= t.failures {
+		return errors.New("421 service not available")
+	}
+	return t.MemoryTransport.Send(from, to, message)
+}
+
+// TestQueue runs several test cases to check the delivery, retry and
+// dead-lettering of mail by Queue.
+func TestQueue(t *testing.T) {
+	tests := []struct {
+		name      string
+		failures  int
+		delivered int
+		dead      int
+	}{
+		{"Delivered", 0, 3, 0},
+		{"Retried", 2, 3, 0},
+		{"Dead-lettered", 5, 0, 3},
+	}
+
+	for _, c := range tests {
+		t.Run(c.name, func(t *testing.T) {
+			transport := &flakyTransport{failures: c.failures, attempts: map[string]int{}}
+			store := NewMemoryQueueStore()
+			var mu sync.Mutex
+			errorCount := 0
+			queue, err := NewQueue(transport, QueueOptions{
+				Workers:     2,
+				MaxAttempts: 3,
+				Backoff:     time.Millisecond,
+				Store:       store,
+				OnError: func(mail QueuedMail, err error) {
+					mu.Lock()
+					errorCount++
+					mu.Unlock()
+				},
+			})
+			if err != nil {
+				t.Fatal("NewQueue", err)
+			}
+			if err := queue.Start(); err != nil {
+				t.Fatal("Start", err)
+			}
+			for i := 0; i < 3; i++ {
+				if err := queue.Send("richa@abc.com", []string{"a@abc.com"}, []byte(fmt.Sprint("message ", i))); err != nil {
+					t.Fatal("Send", err)
+				}
+			}
+			deadline := time.Now().Add(5 * time.Second)
+			for time.Now().Before(deadline) {
+				if pending, _ := store.Pending(); len(pending) == 0 {
+					break
+				}
+				time.Sleep(5 * time.Millisecond)
+			}
+			if err := queue.Shutdown(context.Background()); err != nil {
+				t.Fatal("Shutdown", err)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			expectedErrors := 3 * c.failures
+			if expectedErrors > 9 {
+				expectedErrors = 9
+			}
+			if len(transport.Messages()) != c.delivered || len(store.DeadLetters()) != c.dead || errorCount != expectedErrors {
+				t.Fatal("Queue", c.name, len(transport.Messages()), len(store.DeadLetters()), errorCount)
+			} else {
+				fmt.Println("Queue-", c.name, "Pass")
+			}
+		})
+	}
+}
+
+// TestQueueShutdown checks that shutting down a queue drains the mail that is ready
+// and that stored mail is delivered by the next queue.
+func TestQueueShutdown(t *testing.T) {
+	store := NewMemoryQueueStore()
+	stopped, _ := NewQueue(NewMemoryTransport(), QueueOptions{Store: store})
+	if err := stopped.Send("richa@abc.com", []string{"a@abc.com"}, []byte("stored")); err != nil {
+		t.Fatal("Send", err)
+	}
+	if err := stopped.Shutdown(context.Background()); err != nil {
+		t.Fatal("Shutdown", err)
+	}
+	if err := stopped.Send("richa@abc.com", []string{"a@abc.com"}, []byte("late")); err == nil || err.Error() != constants.QueueClosed {
+		t.Fatal("Send after Shutdown", err)
+	}
+
+	transport := &flakyTransport{attempts: map[string]int{}, delay: 20 * time.Millisecond}
+	queue, _ := NewQueue(transport, QueueOptions{Workers: 2, Store: store})
+	if err := queue.Start(); err != nil {
+		t.Fatal("Start", err)
+	}
+	for i := 0; i < 5; i++ {
+		if err := queue.Enqueue(NewMessage("richa@abc.com", []string{"a@abc.com"}, fmt.Sprint("Mail ", i))); err != nil {
+			t.Fatal("Enqueue", err)
+		}
+	}
+	if err := queue.Shutdown(context.Background()); err != nil {
+		t.Fatal("Shutdown", err)
+	}
+	if pending, _ := store.Pending(); len(transport.Messages()) != 6 || len(pending) != 0 {
+		t.Fatal("Queue drain", len(transport.Messages()), len(pending))
+	}
+	fmt.Println("Queue shutdown- Pass")
+}
+
+// TestQueueSendBeforeStart checks that mail sent before a queue is started is delivered once.
+func TestQueueSendBeforeStart(t *testing.T) {
+	transport := NewMemoryTransport()
+	store := NewMemoryQueueStore()
+	queue, _ := NewQueue(transport, QueueOptions{Store: store})
+	if err := queue.Send("richa@abc.com", []string{"a@abc.com"}, []byte("early")); err != nil {
+		t.Fatal("Send", err)
+	}
+	if err := queue.Start(); err != nil {
+		t.Fatal("Start", err)
+	}
+	if err := queue.Start(); err != nil {
+		t.Fatal("Start of a started queue", err)
+	}
+	if err := queue.Shutdown(context.Background()); err != nil {
+		t.Fatal("Shutdown", err)
+	}
+	if pending, _ := store.Pending(); len(transport.Messages()) != 1 || len(pending) != 0 {
+		t.Fatal("Mail sent before Start", len(transport.Messages()), len(pending))
+	}
+	fmt.Println("Queue send before start- Pass")
+}
+
+// TestQueuePoll checks that a started queue delivers mail stored by another queue once,
+// and that it does not deliver the mail it is delivering again.
+func TestQueuePoll(t *testing.T) {
+	transport := &flakyTransport{attempts: map[string]int{}, delay: 20 * time.Millisecond}
+	store := NewMemoryQueueStore()
+	queue, _ := NewQueue(transport, QueueOptions{Workers: 1, Store: store, PollInterval: 5 * time.Millisecond})
+	if err := queue.Start(); err != nil {
+		t.Fatal("Start", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := queue.Send("richa@abc.com", []string{"a@abc.com"}, []byte(fmt.Sprint("sent ", i))); err != nil {
+			t.Fatal("Send", err)
+		}
+	}
+	// Mail of a crashed queue sharing the store.
+	store.Add(QueuedMail{ID: "abandoned", From: "richa@abc.com", To: []string{"a@abc.com"}, Message: []byte("abandoned")})
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if pending, _ := store.Pending(); len(pending) == 0 {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if err := queue.Shutdown(context.Background()); err != nil {
+		t.Fatal("Shutdown", err)
+	}
+	if len(transport.Messages()) != 4 {
+		t.Fatal("Polled mail", len(transport.Messages()))
+	}
+	for message, attempts := range transport.attempts {
+		if attempts != 1 {
+			t.Fatal("Mail delivered again", message, attempts)
+		}
+	}
+	fmt.Println("Queue poll- Pass")
+}
+
+// claimedStore is a store shared with a queue that claimed all the mail.
+type claimedStore struct {
+	*MemoryQueueStore
+}
+
+// Update reports that another queue delivers the mail.
+func (s claimedStore) Update(mail QueuedMail) error {
+	return ErrMailClaimLost
+}
+
+// Pending returns no mail, as it is claimed by the other queue.
+func (s claimedStore) Pending() ([]QueuedMail, error) {
+	return nil, nil
+}
+
+// TestQueueClaimLost checks that a queue does not retry mail claimed by another queue.
+func TestQueueClaimLost(t *testing.T) {
+	transport := &flakyTransport{failures: 1, attempts: map[string]int{}}
+	var errs []error
+	var mu sync.Mutex
+	queue, _ := NewQueue(transport, QueueOptions{
+		Store:   claimedStore{NewMemoryQueueStore()},
+		Backoff: time.Millisecond,
+		OnError: func(mail QueuedMail, err error) {
+			mu.Lock()
+			errs = append(errs, err)
+			mu.Unlock()
+		},
+	})
+	queue.Start()
+	if err := queue.Send("richa@abc.com", []string{"a@abc.com"}, []byte("claimed")); err != nil {
+		t.Fatal("Send", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	queue.Shutdown(context.Background())
+	mu.Lock()
+	defer mu.Unlock()
+	if transport.attempts["claimed"] != 1 || len(errs) != 2 || errs[1] != ErrMailClaimLost {
+		t.Fatal("Claim lost", transport.attempts["claimed"], errs)
+	}
+	fmt.Println("Queue claim lost- Pass")
+}
+
+// TestQueueBackoff checks that retry delays double up to the maximum backoff.
+func TestQueueBackoff(t *testing.T) {
+	queue, _ := NewQueue(NewMemoryTransport(), QueueOptions{Backoff: time.Second, MaxBackoff: 5 * time.Second})
+	for attempts, expected := range []time.Duration{time.Second, time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		if delay := queue.backoff(attempts); delay != expected {
+			t.Fatal("backoff", attempts, delay)
+		}
+	}
+	fmt.Println("Queue backoff- Pass")
+}
+
+// TestMongoQueueStore checks storing queued mail against a live database.
+// It is skipped if the database configured in constants is unreachable.
+func TestMongoQueueStore(t *testing.T) {
+	helper := database.NewMongoHelper(constants.DbUser, constants.DbPassword, constants.DbHost, constants.DbPort, constants.Database)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if _, err := helper.Health(ctx); err != nil {
+		t.Skip("MongoDB is unreachable")
+	}
+	store, err := NewMongoQueueStore(helper, "mail_queue_test")
+	if err != nil {
+		t.Fatal("NewMongoQueueStore", err)
+	}
+	defer helper.RemoveCollection("mail_queue_test")
+	defer helper.RemoveCollection("mail_queue_test_dead")
+	transport := &flakyTransport{failures: 1, attempts: map[string]int{}}
+	queue, _ := NewQueue(transport, QueueOptions{MaxAttempts: 1, Store: store})
+	queue.Send("richa@abc.com", []string{"a@abc.com"}, []byte("dead"))
+	if pending, err := store.Pending(); err != nil || len(pending) != 1 || string(pending[0].Message) != "dead" {
+		t.Fatal("Pending", pending, err)
+	}
+	replica, _ := NewMongoQueueStore(helper, "mail_queue_test")
+	if pending, err := replica.Pending(); err != nil || len(pending) != 0 {
+		t.Fatal("Pending of mail claimed by another store", pending, err)
+	}
+	claimed, _ := store.Pending()
+	if err := replica.Update(claimed[0]); err != ErrMailClaimLost {
+		t.Fatal("Update of mail claimed by another store", err)
+	}
+	queue.Start()
+	queue.Shutdown(context.Background())
+	dead, err := store.DeadLetters()
+	if pending, _ := store.Pending(); err != nil || len(pending) != 0 || len(dead) != 1 || dead[0].Attempts != 1 {
+		t.Fatal("DeadLetters", dead, err)
+	}
+	fmt.Println("MongoQueueStore- Pass")
+}
@@ -0,0 +1,243 @@
+package email
+
+import (
+	"github.com/programmer-richa/utility/constants"
+	"github.com/programmer-richa/utility/templates"
+	"bytes"
+	"fmt"
+	"html"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+)
+
+// DefaultLayout is the layout of the emails of a Registry unless set with RegistryOptions.
+const DefaultLayout = "base.gohtml"
+
+// RegistryOptions configures a Registry. Zero values select the defaults.
+type RegistryOptions struct {
+	// Layout is the name of the layout file of the emails that do not select one, DefaultLayout by default.
+	Layout string
+	// Funcs are the functions available to the templates.
+	Funcs template.FuncMap
+	// RawCSS disables the inlining of the styles of rendered emails, see InlineCSS.
+	RawCSS bool
+}
+
+// Registry holds the email templates of an application, parsed once from a file system:
+//
+//	layouts/*.gohtml   base layouts, rendering the "content" template of the email
+//	partials/*.gohtml  templates shared by layouts and emails, e.g. a footer
+//	*.gohtml           emails, named by their file name
+//	*.txt              plain text companions of the emails, e.g. account_verification.txt
+//
+// An email defines its subject line with a "subject" template. An email whose file only defines
+// templates is rendered with its layout, which it selects by defining a "layout" template holding
+// the name of the layout file, e.g. {{ define "layout" }}plain.gohtml{{ end }}.
+// Other emails are complete documents.
+// A Registry is safe for concurrent use.
+type Registry struct {
+	options RegistryOptions
+	emails  map[string]*template.Template
+	// layouts holds the layout file executed for each email, empty for complete documents
+	layouts map[string]string
+	texts   map[string]*texttemplate.Template
+}
+
+// Rendered is an email rendered by a Registry.
+type Rendered struct {
+	Subject string
+	HTML    string
+	// Text is rendered from the plain text companion of the email, or else converted from HTML.
+	Text string
+}
+
+// NewRegistry returns pointer to Registry holding the templates of fsys,
+// e.g. an embed.FS of the templates of a service.
+func NewRegistry(fsys fs.FS, options RegistryOptions) (*Registry, error) {
+	if options.Layout == "" {
+		options.Layout = DefaultLayout
+	}
+	r := &Registry{
+		options: options,
+		emails:  map[string]*template.Template{},
+		layouts: map[string]string{},
+		texts:   map[string]*texttemplate.Template{},
+	}
+	base := template.New("").Funcs(options.Funcs)
+	layouts := map[string]bool{}
+	for _, folder := range []string{"partials", "layouts"} {
+		files, err := fs.Glob(fsys, folder+"/*.gohtml")
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			if err := parseFile(fsys, file, base.New(path.Base(file))); err != nil {
+				return nil, err
+			}
+			if folder == "layouts" {
+				layouts[path.Base(file)] = true
+			}
+		}
+	}
+	files, err := fs.Glob(fsys, "*.gohtml")
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		set, err := base.Clone()
+		if err != nil {
+			return nil, err
+		}
+		email := set.New(file)
+		if err := parseFile(fsys, file, email); err != nil {
+			return nil, err
+		}
+		r.emails[file] = set
+		if email.Tree != nil && strings.TrimSpace(email.Tree.Root.String()) != "" {
+			continue
+		}
+		layout := options.Layout
+		if set.Lookup("layout") != nil {
+			buffer := new(bytes.Buffer)
+			if err := set.ExecuteTemplate(buffer, "layout", nil); err != nil {
+				return nil, err
+			}
+			layout = strings.TrimSpace(buffer.String())
+		}
+		if !layouts[layout] {
+			return nil, fmt.Errorf("%s %q", constants.UnknownLayout, layout)
+		}
+		r.layouts[file] = layout
+	}
+	files, err = fs.Glob(fsys, "*.txt")
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		content, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+		text, err := texttemplate.New(file).Funcs(texttemplate.FuncMap(options.Funcs)).Parse(string(content))
+		if err != nil {
+			return nil, err
+		}
+		r.texts[file] = text
+	}
+	return r, nil
+}
+
+// NewDefaultRegistry returns pointer to Registry holding the email templates embedded by the templates package.
+func NewDefaultRegistry(options RegistryOptions) (*Registry, error) {
+	fsys, err := fs.Sub(templates.Mail, "mail")
+	if err != nil {
+		return nil, err
+	}
+	return NewRegistry(fsys, options)
+}
+
+// parseFile parses the file of fsys into tpl.
+func parseFile(fsys fs.FS, file string, tpl *template.Template) error {
+	content, err := fs.ReadFile(fsys, file)
+	if err != nil {
+		return err
+	}
+	_, err = tpl.Parse(string(content))
+	return err
+}
+
+// Names returns the names of the emails, sorted.
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.emails))
+	for name := range r.emails {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Render renders the email name with data.
+func (r *Registry) Render(name string, data interface{}) (*Rendered, error) {
+	set, ok := r.emails[name]
+	if !ok {
+		return nil, fmt.Errorf("%s %q", constants.UnknownTemplate, name)
+	}
+	rendered := &Rendered{}
+	buffer := new(bytes.Buffer)
+	if set.Lookup("subject") != nil {
+		if err := set.ExecuteTemplate(buffer, "subject", data); err != nil {
+			return nil, err
+		}
+		// The subject is a header rather than HTML, so the escaping of html/template is undone.
+		rendered.Subject = strings.Join(strings.Fields(html.UnescapeString(buffer.String())), " ")
+		buffer.Reset()
+	}
+	executed := name
+	if layout := r.layouts[name]; layout != "" {
+		executed = layout
+	}
+	if err := set.ExecuteTemplate(buffer, executed, data); err != nil {
+		return nil, err
+	}
+	rendered.HTML = buffer.String()
+	if !r.options.RawCSS {
+		inlined, err := InlineCSS(rendered.HTML)
+		if err != nil {
+			return nil, err
+		}
+		rendered.HTML = inlined
+	}
+	if text, ok := r.texts[strings.TrimSuffix(name, path.Ext(name))+".txt"]; ok {
+		buffer.Reset()
+		if err := text.Execute(buffer, data); err != nil {
+			return nil, err
+		}
+		rendered.Text = buffer.String()
+	} else {
+		rendered.Text = HTMLToText(rendered.HTML)
+	}
+	return rendered, nil
+}
+
+// PreviewHandler returns a development handler rendering the emails of registry with sample data,
+// keyed by email name, to preview them in a browser, e.g. mounted with
+// http.Handle("/emails/", http.StripPrefix("/emails", email.PreviewHandler(registry, samples))).
+// The root lists the emails, /<name> renders an email and /<name>?format=text its plain text version.
+// The subject line is sent in the X-Email-Subject header. It must not be exposed in production.
+func PreviewHandler(registry *Registry, samples map[string]interface{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/")
+		if name == "" {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			fmt.Fprint(w, "<!DOCTYPE html>\n<html><head><title>Emails</title></head><body><ul>\n")
+			for _, name := range registry.Names() {
+				fmt.Fprintf(w, "<li><a href=\"%[1]s\">%[1]s</a> (<a href=\"%[1]s?format=text\">text</a>)</li>\n",
+					template.HTMLEscapeString(name))
+			}
+			fmt.Fprint(w, "</ul></body></html>\n")
+			return
+		}
+		if _, ok := registry.emails[name]; !ok {
+			http.NotFound(w, r)
+			return
+		}
+		rendered, err := registry.Render(name, samples[name])
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("X-Email-Subject", rendered.Subject)
+		if r.URL.Query().Get("format") == "text" {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			fmt.Fprint(w, rendered.Text)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, rendered.HTML)
+	})
+}
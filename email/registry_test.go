@@ -0,0 +1,203 @@
+package email
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// testTemplates returns the templates of a registry with two layouts, a partial,
+// emails using the layouts and a complete document.
+func testTemplates() fstest.MapFS {
+	return fstest.MapFS{
+		"layouts/base.gohtml":  {Data: []byte(`<html><head><style>p{color:red}</style></head><body>{{ block "content" . }}{{ end }}{{ template "footer" . }}</body></html>`)},
+		"layouts/plain.gohtml": {Data: []byte(`<div>{{ block "content" . }}{{ end }}</div>`)},
+		"partials/footer.gohtml": {Data: []byte(`{{ define "footer" }}<p class="footer">Bye {{ .Name }}</p>{{ end }}`)},
+		"welcome.gohtml": {Data: []byte(`{{ define "subject" }}Welcome {{ .Name }} &
+			friends{{ end }}
+{{ define "content" }}<p>Hi {{ .Name | upper }}</p>{{ end }}`)},
+		"welcome.txt": {Data: []byte(`Hi {{ .Name | upper }}`)},
+		"notice.gohtml": {Data: []byte(`{{ define "layout" }}plain.gohtml{{ end }}{{ define "content" }}<b>Notice</b>{{ end }}`)},
+		"raw.gohtml":    {Data: []byte(`<i>{{ .Name }}</i>`)},
+	}
+}
+
+// TestRegistry runs several test cases to check the rendering of emails with layouts,
+// partials, subject lines and plain text companions.
+func TestRegistry(t *testing.T) {
+	funcs := map[string]interface{}{"upper": strings.ToUpper}
+	registry, err := NewRegistry(testTemplates(), RegistryOptions{Funcs: funcs})
+	if err != nil {
+		t.Fatal("NewRegistry", err)
+	}
+	defaults, err := NewDefaultRegistry(RegistryOptions{})
+	if err != nil {
+		t.Fatal("NewDefaultRegistry", err)
+	}
+	data := map[string]string{"Name": "Richa", "URL": "http://abc.com/verify", "Designer": "Programmer Richa"}
+	tests := []struct {
+		name     string
+		registry *Registry
+		template string
+		subject  string
+		html     []string
+		text     string
+		valid    bool
+	}{
+		{
+			"Base layout with partial",
+			registry,
+			"welcome.gohtml",
+			"Welcome Richa & friends",
+			[]string{`<body><p style="color: red">Hi RICHA</p><p class="footer" style="color: red">Bye Richa</p></body>`},
+			"Hi RICHA",
+			true,
+		}, {
+			"Selected layout",
+			registry,
+			"notice.gohtml",
+			"",
+			[]string{"<div><b>Notice</b></div>"},
+			"Notice",
+			true,
+		}, {
+			"Complete document",
+			registry,
+			"raw.gohtml",
+			"",
+			[]string{"<i>Richa</i>"},
+			"Richa",
+			true,
+		}, {
+			"Unknown template",
+			registry,
+			"missing.gohtml",
+			"",
+			nil,
+			"",
+			false,
+		}, {
+			"Embedded account verification",
+			defaults,
+			"account_verification.gohtml",
+			"Verify your account",
+			[]string{"<title>Verify your account</title>", `href="http://abc.com/verify"`, "Programmer Richa", `<td class="button" height="45" style="text-align: center;`},
+			"http://abc.com/verify",
+			true,
+		},
+	}
+
+	for _, c := range tests {
+		t.Run(c.name, func(t *testing.T) {
+			rendered, err := c.registry.Render(c.template, data)
+			if (err == nil) != c.valid {
+				t.Fatal("Registry", c.name, err)
+			}
+			if !c.valid {
+				fmt.Println("Registry-", c.name, "Pass")
+				return
+			}
+			if rendered.Subject != c.subject || !strings.Contains(rendered.Text, c.text) || strings.Contains(rendered.HTML, "<style") {
+				t.Fatalf("Registry %s %q %q %q", c.name, rendered.Subject, rendered.Text, rendered.HTML)
+			}
+			for _, html := range c.html {
+				if !strings.Contains(rendered.HTML, html) {
+					t.Fatalf("Registry %s %q", c.name, rendered.HTML)
+				}
+			}
+			fmt.Println("Registry-", c.name, "Pass")
+		})
+	}
+}
+
+// TestDefaultRegistryData runs several test cases to check that the embedded emails render
+// the data passed by Verification, without the optional designer credits.
+func TestDefaultRegistryData(t *testing.T) {
+	registry, err := NewDefaultRegistry(RegistryOptions{})
+	if err != nil {
+		t.Fatal("NewDefaultRegistry", err)
+	}
+	tests := []struct {
+		name     string
+		template string
+		data     map[string]interface{}
+		credits  string
+	}{
+		{"Account verification", "account_verification.gohtml", map[string]interface{}{"Name": "Richa"}, ""},
+		{"Password reset", "password_reset.gohtml", map[string]interface{}{"Name": "Richa"}, ""},
+		{"Designer without site", "password_reset.gohtml", map[string]interface{}{"Name": "Richa", "Designer": "Programmer Richa"},
+			"Designed by Programmer Richa"},
+		{"Designer with site", "account_verification.gohtml", map[string]interface{}{"Name": "Richa", "Designer": "Programmer Richa",
+			"DesignerSite": "http://abcd.com"}, "Designed by Programmer Richa (http://abcd.com)"},
+	}
+
+	for _, c := range tests {
+		t.Run(c.name, func(t *testing.T) {
+			c.data["URL"] = "http://abc.com/verify?token=abc"
+			rendered, err := registry.Render(c.template, c.data)
+			if err != nil {
+				t.Fatal("Render", c.name, err)
+			}
+			for _, body := range []string{rendered.Text, rendered.HTML} {
+				if strings.Contains(body, "<no value>") || !strings.Contains(body, "http://abc.com/verify?token=abc") ||
+					strings.Contains(body, "Designed by") != (c.credits != "") {
+					t.Fatalf("Render %s %q", c.name, body)
+				}
+			}
+			if !strings.HasSuffix(strings.TrimSpace(rendered.Text), c.credits) {
+				t.Fatalf("Render %s %q", c.name, rendered.Text)
+			}
+			fmt.Println("Default registry data-", c.name, "Pass")
+		})
+	}
+}
+
+// TestRegistryLayout checks that an email selecting a missing layout is reported.
+func TestRegistryLayout(t *testing.T) {
+	templates := testTemplates()
+	templates["notice.gohtml"] = &fstest.MapFile{Data: []byte(`{{ define "layout" }}fancy.gohtml{{ end }}`)}
+	if _, err := NewRegistry(templates, RegistryOptions{Funcs: map[string]interface{}{"upper": strings.ToUpper}}); err == nil ||
+		!strings.Contains(err.Error(), "fancy.gohtml") {
+		t.Fatal("Registry layout", err)
+	}
+	fmt.Println("Registry layout- Pass")
+}
+
+// TestPreviewHandler runs several test cases to check the pages of the preview handler.
+func TestPreviewHandler(t *testing.T) {
+	registry, err := NewRegistry(testTemplates(), RegistryOptions{Funcs: map[string]interface{}{"upper": strings.ToUpper}})
+	if err != nil {
+		t.Fatal("NewRegistry", err)
+	}
+	handler := PreviewHandler(registry, map[string]interface{}{"welcome.gohtml": map[string]string{"Name": "Richa"}})
+	tests := []struct {
+		name    string
+		path    string
+		status  int
+		subject string
+		body    string
+	}{
+		{"List", "/", http.StatusOK, "", `<a href="welcome.gohtml?format=text">text</a>`},
+		{"HTML", "/welcome.gohtml", http.StatusOK, "Welcome Richa & friends", "<p style=\"color: red\">Hi RICHA</p>"},
+		{"Text", "/welcome.gohtml?format=text", http.StatusOK, "Welcome Richa & friends", "Hi RICHA"},
+		{"Missing sample", "/raw.gohtml", http.StatusOK, "", "<i></i>"},
+		{"Unknown", "/missing.gohtml", http.StatusNotFound, "", ""},
+	}
+
+	for _, c := range tests {
+		t.Run(c.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, c.path, nil))
+			body, _ := ioutil.ReadAll(recorder.Body)
+			if recorder.Code != c.status || recorder.Header().Get("X-Email-Subject") != c.subject || !strings.Contains(string(body), c.body) {
+				t.Fatalf("Preview %s %d %q %q", c.name, recorder.Code, recorder.Header().Get("X-Email-Subject"), body)
+			} else {
+				fmt.Println("Preview-", c.name, "Pass")
+			}
+		})
+	}
+}
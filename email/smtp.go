@@ -0,0 +1,371 @@
+package email
+
+import (
+	"github.com/programmer-richa/utility/constants"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default timeouts of SMTPTransport.
+const (
+	DefaultDialTimeout    = 30 * time.Second
+	DefaultCommandTimeout = time.Minute
+)
+
+// TLSMode selects how SMTPTransport secures connections.
+type TLSMode int
+
+const (
+	// TLSOpportunistic upgrades the connection with STARTTLS if the server supports it.
+	TLSOpportunistic TLSMode = iota
+	// TLSRequired upgrades the connection with STARTTLS and fails if the server does not support it.
+	TLSRequired
+	// TLSImplicit connects with TLS from the start, as expected on port 465.
+	TLSImplicit
+	// TLSNone never encrypts the connection. PLAIN and LOGIN auth are then refused unless the server is local.
+	TLSNone
+)
+
+// Authentication mechanisms of SMTPTransport.
+const (
+	// AuthAuto selects the best mechanism supported by the server among CRAM-MD5, PLAIN and LOGIN.
+	AuthAuto    = ""
+	AuthPlain   = "PLAIN"
+	AuthLogin   = "LOGIN"
+	AuthCRAMMD5 = "CRAM-MD5"
+	// AuthXOAUTH2 authenticates with an OAuth 2.0 access token held by Password, e.g. for Gmail.
+	AuthXOAUTH2 = "XOAUTH2"
+)
+
+// SMTPTransport delivers messages to an SMTP server.
+// Every call to Send uses a new connection; use Dial to send batches over a single connection.
+type SMTPTransport struct {
+	Server string
+	Port   int
+	// Username and Password authenticate with the server unless Username is empty.
+	Username string
+	Password string
+	TLS      TLSMode
+	// TLSConfig defaults to verifying the certificate of Server.
+	TLSConfig *tls.Config
+	// Auth is the authentication mechanism, AuthAuto by default.
+	Auth string
+	// HelloName is the host name sent with EHLO, "localhost" by default.
+	HelloName string
+	// DialTimeout bounds the connection to the server, DefaultDialTimeout by default.
+	DialTimeout time.Duration
+	// CommandTimeout bounds every command, including the transfer of the message,
+	// DefaultCommandTimeout by default.
+	CommandTimeout time.Duration
+}
+
+// NewSMTPTransport returns pointer to SMTPTransport
+func NewSMTPTransport(server string, port int, username string, password string) *SMTPTransport {
+	return &SMTPTransport{Server: server, Port: port, Username: username, Password: password}
+}
+
+// Send delivers the message to the server over a new connection.
+func (t *SMTPTransport) Send(from string, to []string, message []byte) error {
+	if len(to) == 0 {
+		return errors.New(constants.NoRecipients)
+	}
+	connection, err := t.Dial()
+	if err != nil {
+		return err
+	}
+	defer connection.Close()
+	return connection.Send(from, to, message)
+}
+
+// Dial connects and authenticates to the server.
+// The returned connection delivers messages until it is closed, e.g. to send a batch.
+func (t *SMTPTransport) Dial() (*SMTPConnection, error) {
+	connection := &SMTPConnection{transport: t}
+	if err := connection.connect(); err != nil {
+		return nil, err
+	}
+	return connection, nil
+}
+
+// SMTPConnection delivers messages over a single connection to the server of an SMTPTransport.
+// It reconnects if the server closed the connection. It is safe for concurrent use,
+// messages being sent one at a time.
+type SMTPConnection struct {
+	transport *SMTPTransport
+	mu        sync.Mutex
+	conn      net.Conn
+	client    *smtp.Client
+}
+
+// Send delivers the message over the connection.
+func (c *SMTPConnection) Send(from string, to []string, message []byte) error {
+	if len(to) == 0 {
+		return errors.New(constants.NoRecipients)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// A connection left idle may have been closed by the server.
+	if c.client != nil && c.step(c.client.Noop) != nil {
+		c.drop()
+	}
+	if c.client == nil {
+		if err := c.connect(); err != nil {
+			return err
+		}
+	}
+	if err := c.send(from, to, message); err != nil {
+		// The transaction is aborted so that the connection can send the next message.
+		if c.step(c.client.Reset) != nil {
+			c.drop()
+		}
+		return err
+	}
+	return nil
+}
+
+// Close ends the session with the server.
+func (c *SMTPConnection) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.client == nil {
+		return nil
+	}
+	err := c.step(c.client.Quit)
+	c.drop()
+	return err
+}
+
+// send runs the SMTP transaction of a message.
+func (c *SMTPConnection) send(from string, to []string, message []byte) error {
+	if err := c.step(func() error { return c.client.Mail(from) }); err != nil {
+		return err
+	}
+	for _, recipient := range to {
+		if err := c.step(func() error { return c.client.Rcpt(recipient) }); err != nil {
+			return err
+		}
+	}
+	return c.step(func() error {
+		writer, err := c.client.Data()
+		if err != nil {
+			return err
+		}
+		if _, err := writer.Write(message); err != nil {
+			writer.Close()
+			return err
+		}
+		return writer.Close()
+	})
+}
+
+// connect opens the connection, secures it and authenticates. It must be called with c.mu held,
+// except by Dial.
+func (c *SMTPConnection) connect() error {
+	t := c.transport
+	address := net.JoinHostPort(t.Server, strconv.Itoa(t.Port))
+	dialer := &net.Dialer{Timeout: t.dialTimeout()}
+	var err error
+	if t.TLS == TLSImplicit {
+		c.conn, err = tls.DialWithDialer(dialer, "tcp", address, t.tlsConfig())
+	} else {
+		c.conn, err = dialer.Dial("tcp", address)
+	}
+	if err != nil {
+		return err
+	}
+	// The greeting of the server is read by NewClient.
+	c.conn.SetDeadline(time.Now().Add(t.commandTimeout()))
+	c.client, err = smtp.NewClient(c.conn, t.Server)
+	if err != nil {
+		c.conn.Close()
+		c.conn = nil
+		return err
+	}
+	if err := c.handshake(); err != nil {
+		c.drop()
+		return err
+	}
+	return nil
+}
+
+// handshake greets the server, upgrades the connection to TLS and authenticates.
+func (c *SMTPConnection) handshake() error {
+	t := c.transport
+	hello := t.HelloName
+	if hello == "" {
+		hello = "localhost"
+	}
+	if err := c.step(func() error { return c.client.Hello(hello) }); err != nil {
+		return err
+	}
+	if t.TLS == TLSOpportunistic || t.TLS == TLSRequired {
+		if ok, _ := c.client.Extension("STARTTLS"); ok {
+			if err := c.step(func() error { return c.client.StartTLS(t.tlsConfig()) }); err != nil {
+				return err
+			}
+		} else if t.TLS == TLSRequired {
+			return errors.New(constants.TLSUnavailable)
+		}
+	}
+	if t.Username == "" {
+		return nil
+	}
+	auth, err := c.auth()
+	if err != nil {
+		return err
+	}
+	return c.step(func() error { return c.client.Auth(auth) })
+}
+
+// auth returns the authentication of the transport, selecting the mechanism if it is AuthAuto.
+func (c *SMTPConnection) auth() (smtp.Auth, error) {
+	t := c.transport
+	mechanism := t.Auth
+	if mechanism == AuthAuto {
+		ok, advertised := c.client.Extension("AUTH")
+		if !ok {
+			return nil, fmt.Errorf("%s %s", constants.UnsupportedAuth, "AUTH")
+		}
+		supported := map[string]bool{}
+		for _, name := range strings.Fields(strings.ToUpper(advertised)) {
+			supported[name] = true
+		}
+		_, secure := c.client.TLSConnectionState()
+		// CRAM-MD5 does not reveal the password, so it is preferred on unencrypted connections.
+		preferred := []string{AuthPlain, AuthLogin, AuthCRAMMD5}
+		if !secure {
+			preferred = []string{AuthCRAMMD5, AuthPlain, AuthLogin}
+		}
+		for _, name := range preferred {
+			if supported[name] {
+				mechanism = name
+				break
+			}
+		}
+	}
+	switch mechanism {
+	case AuthPlain:
+		return smtp.PlainAuth("", t.Username, t.Password, t.Server), nil
+	case AuthLogin:
+		return &loginAuth{username: t.Username, password: t.Password, host: t.Server}, nil
+	case AuthCRAMMD5:
+		return smtp.CRAMMD5Auth(t.Username, t.Password), nil
+	case AuthXOAUTH2:
+		return &xoauth2Auth{username: t.Username, token: t.Password, host: t.Server}, nil
+	}
+	return nil, fmt.Errorf("%s %s", constants.UnsupportedAuth, mechanism)
+}
+
+// step runs an SMTP command within the command timeout.
+func (c *SMTPConnection) step(command func() error) error {
+	c.conn.SetDeadline(time.Now().Add(c.transport.commandTimeout()))
+	return command()
+}
+
+// drop closes the connection without ending the session.
+func (c *SMTPConnection) drop() {
+	if c.client != nil {
+		c.client.Close()
+	}
+	c.client = nil
+	c.conn = nil
+}
+
+// tlsConfig returns the TLS configuration of the transport.
+func (t *SMTPTransport) tlsConfig() *tls.Config {
+	if t.TLSConfig != nil {
+		return t.TLSConfig
+	}
+	return &tls.Config{ServerName: t.Server}
+}
+
+// dialTimeout returns the connection timeout of the transport.
+func (t *SMTPTransport) dialTimeout() time.Duration {
+	if t.DialTimeout > 0 {
+		return t.DialTimeout
+	}
+	return DefaultDialTimeout
+}
+
+// commandTimeout returns the command timeout of the transport.
+func (t *SMTPTransport) commandTimeout() time.Duration {
+	if t.CommandTimeout > 0 {
+		return t.CommandTimeout
+	}
+	return DefaultCommandTimeout
+}
+
+// isLocalhost tests if host is the local machine, to which credentials may be sent in clear.
+func isLocalhost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// loginAuth implements the LOGIN authentication mechanism.
+type loginAuth struct {
+	username string
+	password string
+	host     string
+}
+
+// Start begins the LOGIN authentication, refusing to send credentials in clear to a remote server.
+func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS && !isLocalhost(server.Name) {
+		return "", nil, errors.New(constants.UnencryptedAuth)
+	}
+	if server.Name != a.host {
+		return "", nil, errors.New(constants.WrongAuthHost)
+	}
+	return AuthLogin, nil, nil
+}
+
+// Next answers the username and password challenges of the server.
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	switch strings.ToLower(strings.TrimSpace(string(fromServer))) {
+	case "username:":
+		return []byte(a.username), nil
+	case "password:":
+		return []byte(a.password), nil
+	}
+	return nil, fmt.Errorf("%s %q", constants.UnexpectedChallenge, fromServer)
+}
+
+// xoauth2Auth implements the XOAUTH2 authentication mechanism.
+type xoauth2Auth struct {
+	username string
+	token    string
+	host     string
+}
+
+// Start sends the access token, refusing to send it in clear to a remote server.
+func (a *xoauth2Auth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS && !isLocalhost(server.Name) {
+		return "", nil, errors.New(constants.UnencryptedAuth)
+	}
+	if server.Name != a.host {
+		return "", nil, errors.New(constants.WrongAuthHost)
+	}
+	return AuthXOAUTH2, []byte("user=" + a.username + "\x01auth=Bearer " + a.token + "\x01\x01"), nil
+}
+
+// Next answers the error challenge sent by the server on failure with an empty response,
+// after which the server reports the failure.
+func (a *xoauth2Auth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if more {
+		return []byte{}, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,362 @@
+package email
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSMTPServer is a local SMTP server recording the messages it receives, with line endings read as LF.
+type fakeSMTPServer struct {
+	listener  net.Listener
+	tlsConfig *tls.Config
+	// startTLS advertises the STARTTLS extension.
+	startTLS bool
+	// auth lists the advertised authentication mechanisms.
+	auth     []string
+	username string
+	password string
+	// silent makes the server never greet clients.
+	silent bool
+
+	mu          sync.Mutex
+	connections []net.Conn
+	hellos      []string
+	messages    []SentMessage
+	secure      []bool
+}
+
+// newFakeSMTPServer starts a fake server configured by configure, using TLS from the start if implicitTLS is set.
+// It returns the server and the TLS configuration trusting its certificate.
+func newFakeSMTPServer(t *testing.T, implicitTLS bool, configure func(s *fakeSMTPServer)) (*fakeSMTPServer, *tls.Config) {
+	certificateServer := httptest.NewUnstartedServer(nil)
+	certificateServer.StartTLS()
+	certificateServer.Close()
+	roots := x509.NewCertPool()
+	roots.AddCert(certificateServer.Certificate())
+	s := &fakeSMTPServer{
+		tlsConfig: &tls.Config{Certificates: certificateServer.TLS.Certificates},
+		username:  "richa",
+		password:  "secret",
+	}
+	configure(s)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("Listen", err)
+	}
+	if implicitTLS {
+		listener = tls.NewListener(listener, s.tlsConfig)
+	}
+	s.listener = listener
+	go s.serve()
+	return s, &tls.Config{RootCAs: roots, ServerName: "127.0.0.1"}
+}
+
+// port returns the port the server listens on.
+func (s *fakeSMTPServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+// close stops the server and closes its connections.
+func (s *fakeSMTPServer) close() {
+	s.listener.Close()
+	s.dropConnections()
+}
+
+// dropConnections closes the open connections, as servers do with idle ones.
+func (s *fakeSMTPServer) dropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, conn := range s.connections {
+		conn.Close()
+	}
+}
+
+// serve accepts connections until the server is closed.
+func (s *fakeSMTPServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.connections = append(s.connections, conn)
+		s.mu.Unlock()
+		go s.handle(conn)
+	}
+}
+
+// handle runs an SMTP session.
+func (s *fakeSMTPServer) handle(conn net.Conn) {
+	defer conn.Close()
+	if s.silent {
+		bufio.NewReader(conn).ReadString('\n')
+		return
+	}
+	_, secure := conn.(*tls.Conn)
+	text := textproto.NewConn(conn)
+	text.PrintfLine("220 fake ESMTP")
+	var from string
+	var to []string
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			text.PrintfLine("500 empty command")
+			continue
+		}
+		switch strings.ToUpper(fields[0]) {
+		case "EHLO":
+			s.mu.Lock()
+			s.hellos = append(s.hellos, fields[1])
+			s.mu.Unlock()
+			lines := []string{"fake"}
+			if s.startTLS && !secure {
+				lines = append(lines, "STARTTLS")
+			}
+			if len(s.auth) > 0 {
+				lines = append(lines, "AUTH "+strings.Join(s.auth, " "))
+			}
+			for _, extension := range lines {
+				text.PrintfLine("250-%s", extension)
+			}
+			text.PrintfLine("250 8BITMIME")
+		case "STARTTLS":
+			text.PrintfLine("220 ready")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if tlsConn.Handshake() != nil {
+				return
+			}
+			conn, secure = tlsConn, true
+			text = textproto.NewConn(conn)
+		case "AUTH":
+			if s.authenticate(text, fields[1:]) {
+				text.PrintfLine("235 authenticated")
+			} else {
+				text.PrintfLine("535 authentication failed")
+			}
+		case "MAIL":
+			from = strings.Trim(strings.TrimPrefix(fields[1], "FROM:"), "<>")
+			to = nil
+			text.PrintfLine("250 ok")
+		case "RCPT":
+			to = append(to, strings.Trim(strings.TrimPrefix(fields[1], "TO:"), "<>"))
+			text.PrintfLine("250 ok")
+		case "DATA":
+			text.PrintfLine("354 go ahead")
+			data, err := text.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.messages = append(s.messages, SentMessage{From: from, To: to, Message: data})
+			s.secure = append(s.secure, secure)
+			s.mu.Unlock()
+			text.PrintfLine("250 queued")
+		case "RSET", "NOOP":
+			text.PrintfLine("250 ok")
+		case "QUIT":
+			text.PrintfLine("221 bye")
+			return
+		default:
+			text.PrintfLine("502 unknown command")
+		}
+	}
+}
+
+// authenticate runs the AUTH exchange of mechanism and checks the credentials.
+func (s *fakeSMTPServer) authenticate(text *textproto.Conn, arguments []string) bool {
+	challenge := func(prompt string) string {
+		text.PrintfLine("334 %s", base64.StdEncoding.EncodeToString([]byte(prompt)))
+		line, _ := text.ReadLine()
+		decoded, _ := base64.StdEncoding.DecodeString(line)
+		return string(decoded)
+	}
+	initial := ""
+	if len(arguments) > 1 {
+		decoded, _ := base64.StdEncoding.DecodeString(arguments[1])
+		initial = string(decoded)
+	}
+	switch arguments[0] {
+	case "PLAIN":
+		return initial == "\x00"+s.username+"\x00"+s.password
+	case "LOGIN":
+		return challenge("Username:") == s.username && challenge("Password:") == s.password
+	case "CRAM-MD5":
+		nonce := "<1896.697170952@fake>"
+		mac := hmac.New(md5.New, []byte(s.password))
+		mac.Write([]byte(nonce))
+		return challenge(nonce) == s.username+" "+hex.EncodeToString(mac.Sum(nil))
+	case "XOAUTH2":
+		return initial == "user="+s.username+"\x01auth=Bearer "+s.password+"\x01\x01"
+	}
+	return false
+}
+
+// TestSMTPTransport runs several test cases to check the TLS modes and
+// authentication mechanisms of SMTPTransport against a fake server.
+func TestSMTPTransport(t *testing.T) {
+	tests := []struct {
+		name        string
+		implicitTLS bool
+		server      func(s *fakeSMTPServer)
+		transport   func(t *SMTPTransport)
+		valid       bool
+		secure      bool
+	}{
+		{
+			"Plain auth without TLS",
+			false,
+			func(s *fakeSMTPServer) { s.auth = []string{"PLAIN"} },
+			func(t *SMTPTransport) { t.TLS = TLSNone },
+			true,
+			false,
+		}, {
+			"Opportunistic STARTTLS",
+			false,
+			func(s *fakeSMTPServer) { s.startTLS, s.auth = true, []string{"PLAIN", "LOGIN"} },
+			func(t *SMTPTransport) {},
+			true,
+			true,
+		}, {
+			"Opportunistic without STARTTLS",
+			false,
+			func(s *fakeSMTPServer) {},
+			func(t *SMTPTransport) { t.Username = "" },
+			true,
+			false,
+		}, {
+			"Required STARTTLS missing",
+			false,
+			func(s *fakeSMTPServer) { s.auth = []string{"PLAIN"} },
+			func(t *SMTPTransport) { t.TLS = TLSRequired },
+			false,
+			false,
+		}, {
+			"Implicit TLS",
+			true,
+			func(s *fakeSMTPServer) { s.auth = []string{"PLAIN"} },
+			func(t *SMTPTransport) { t.TLS = TLSImplicit },
+			true,
+			true,
+		}, {
+			"Login auth",
+			false,
+			func(s *fakeSMTPServer) { s.startTLS, s.auth = true, []string{"LOGIN"} },
+			func(t *SMTPTransport) { t.TLS = TLSRequired },
+			true,
+			true,
+		}, {
+			"CRAM-MD5 preferred without TLS",
+			false,
+			func(s *fakeSMTPServer) { s.auth = []string{"LOGIN", "CRAM-MD5"} },
+			func(t *SMTPTransport) { t.TLS = TLSNone },
+			true,
+			false,
+		}, {
+			"XOAUTH2",
+			false,
+			func(s *fakeSMTPServer) { s.startTLS, s.auth, s.password = true, []string{"XOAUTH2"}, "token" },
+			func(t *SMTPTransport) { t.Auth, t.Password = AuthXOAUTH2, "token" },
+			true,
+			true,
+		}, {
+			"Wrong password",
+			false,
+			func(s *fakeSMTPServer) { s.startTLS, s.auth = true, []string{"PLAIN"} },
+			func(t *SMTPTransport) { t.Password = "wrong" },
+			false,
+			false,
+		}, {
+			"Unsupported mechanism",
+			false,
+			func(s *fakeSMTPServer) { s.startTLS, s.auth = true, []string{"GSSAPI"} },
+			func(t *SMTPTransport) {},
+			false,
+			false,
+		},
+	}
+
+	for _, c := range tests {
+		t.Run(c.name, func(t *testing.T) {
+			server, clientTLS := newFakeSMTPServer(t, c.implicitTLS, c.server)
+			defer server.close()
+			transport := NewSMTPTransport("127.0.0.1", server.port(), "richa", "secret")
+			transport.TLSConfig = clientTLS
+			transport.HelloName = "mailer.abc.com"
+			c.transport(transport)
+			err := transport.Send("richa@abc.com", []string{"a@abc.com", "b@abc.com"}, []byte("Subject: Hi\r\n\r\nHello\r\n"))
+			if (err == nil) != c.valid {
+				t.Fatal("SMTPTransport", c.name, err)
+			}
+			server.mu.Lock()
+			defer server.mu.Unlock()
+			if c.valid && (len(server.messages) != 1 || server.secure[0] != c.secure || server.hellos[0] != "mailer.abc.com" ||
+				server.messages[0].From != "richa@abc.com" || strings.Join(server.messages[0].To, ",") != "a@abc.com,b@abc.com" ||
+				string(server.messages[0].Message) != "Subject: Hi\n\nHello\n") {
+				t.Fatal("SMTPTransport", c.name, server.messages, server.secure)
+			}
+			fmt.Println("SMTPTransport-", c.name, "Pass")
+		})
+	}
+}
+
+// TestSMTPConnection checks that a connection sends batches over a single session
+// and reconnects when the server closes it.
+func TestSMTPConnection(t *testing.T) {
+	server, clientTLS := newFakeSMTPServer(t, false, func(s *fakeSMTPServer) { s.startTLS, s.auth = true, []string{"PLAIN"} })
+	defer server.close()
+	transport := NewSMTPTransport("127.0.0.1", server.port(), "richa", "secret")
+	transport.TLSConfig = clientTLS
+	connection, err := transport.Dial()
+	if err != nil {
+		t.Fatal("Dial", err)
+	}
+	defer connection.Close()
+	for i := 0; i < 3; i++ {
+		if err := connection.Send("richa@abc.com", []string{"a@abc.com"}, []byte(fmt.Sprint("Subject: ", i, "\r\n\r\n"))); err != nil {
+			t.Fatal("Send", i, err)
+		}
+	}
+	server.mu.Lock()
+	batch := len(server.connections)
+	server.mu.Unlock()
+	server.dropConnections()
+	if err := connection.Send("richa@abc.com", []string{"a@abc.com"}, []byte("Subject: again\r\n\r\n")); err != nil {
+		t.Fatal("Send after drop", err)
+	}
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if batch != 1 || len(server.connections) != 2 || len(server.messages) != 4 {
+		t.Fatal("SMTPConnection", batch, len(server.connections), len(server.messages))
+	}
+	fmt.Println("SMTPConnection- Pass")
+}
+
+// TestSMTPTimeout checks that a server that does not answer fails the delivery within the command timeout.
+func TestSMTPTimeout(t *testing.T) {
+	server, _ := newFakeSMTPServer(t, false, func(s *fakeSMTPServer) { s.silent = true })
+	defer server.close()
+	transport := NewSMTPTransport("127.0.0.1", server.port(), "", "")
+	transport.CommandTimeout = 100 * time.Millisecond
+	start := time.Now()
+	if err := transport.Send("richa@abc.com", []string{"a@abc.com"}, []byte("\r\n")); err == nil || time.Since(start) > time.Second {
+		t.Fatal("SMTP timeout", err, time.Since(start))
+	}
+	fmt.Println("SMTP timeout- Pass")
+}
@@ -0,0 +1,153 @@
+package email
+
+import (
+	"regexp"
+	"strings"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// blankLines matches the runs of blank lines collapsed by HTMLToText.
+var blankLines = regexp.MustCompile(`\n{3,}`)
+
+// HTMLToText returns the plain text version of an HTML email, e.g. to be sent as its alternative.
+// Paragraphs, headings and table rows are separated by line breaks, list items are prefixed
+// with "- " and links are kept by writing their URL after their text.
+// The content of head, style and script elements is dropped.
+func HTMLToText(source string) string {
+	converter := &textConverter{}
+	tokenizer := html.NewTokenizer(strings.NewReader(source))
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			text := blankLines.ReplaceAllString(converter.text.String(), "\n\n")
+			return strings.TrimSpace(text)
+		case html.TextToken:
+			if converter.skip == 0 {
+				converter.write(string(tokenizer.Text()))
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			converter.start(tokenizer.Token())
+		case html.EndTagToken:
+			converter.end(tokenizer.Token())
+		}
+	}
+}
+
+// textConverter accumulates the text of an HTML document.
+type textConverter struct {
+	text strings.Builder
+	// skip counts the open elements whose content is dropped.
+	skip int
+	// links holds the URLs of the open links, to be written after their text.
+	links []string
+	// linkStart holds the length of the text when the open links started.
+	linkStart []int
+}
+
+// write appends text with its whitespace collapsed.
+func (c *textConverter) write(text string) {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		if text != "" {
+			c.space()
+		}
+		return
+	}
+	if strings.TrimLeft(text, " \t\r\n") != text {
+		c.space()
+	}
+	c.text.WriteString(strings.Join(words, " "))
+	if strings.TrimRight(text, " \t\r\n") != text {
+		c.space()
+	}
+}
+
+// space separates the next word from the previous one.
+func (c *textConverter) space() {
+	text := c.text.String()
+	if text != "" && !strings.HasSuffix(text, " ") && !strings.HasSuffix(text, "\n") {
+		c.text.WriteString(" ")
+	}
+}
+
+// newline ends the current line, writing count line breaks in total.
+func (c *textConverter) newline(count int) {
+	text := strings.TrimRight(c.text.String(), " ")
+	if text == "" {
+		return
+	}
+	c.text.Reset()
+	c.text.WriteString(text)
+	for i := len(text) - len(strings.TrimRight(text, "\n")); i < count; i++ {
+		c.text.WriteString("\n")
+	}
+}
+
+// start handles an opening tag.
+func (c *textConverter) start(token html.Token) {
+	switch token.DataAtom {
+	case atom.Head, atom.Style, atom.Script, atom.Title:
+		if token.Type == html.StartTagToken {
+			c.skip++
+		}
+	case atom.Br:
+		c.newline(1)
+	case atom.P, atom.Div, atom.Table, atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Ul, atom.Ol, atom.Hr:
+		c.newline(2)
+	case atom.Tr:
+		c.newline(1)
+	case atom.Td, atom.Th:
+		c.space()
+	case atom.Li:
+		c.newline(1)
+		c.text.WriteString("- ")
+	case atom.Img:
+		if alt := attribute(token, "alt"); alt != "" && c.skip == 0 {
+			c.write(" " + alt + " ")
+		}
+	case atom.A:
+		c.links = append(c.links, attribute(token, "href"))
+		c.linkStart = append(c.linkStart, c.text.Len())
+	}
+}
+
+// end handles a closing tag.
+func (c *textConverter) end(token html.Token) {
+	switch token.DataAtom {
+	case atom.Head, atom.Style, atom.Script, atom.Title:
+		if c.skip > 0 {
+			c.skip--
+		}
+	case atom.P, atom.Div, atom.Table, atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Ul, atom.Ol:
+		c.newline(2)
+	case atom.Tr, atom.Li:
+		c.newline(1)
+	case atom.A:
+		if len(c.links) == 0 {
+			return
+		}
+		last := len(c.links) - 1
+		href, start := c.links[last], c.linkStart[last]
+		c.links, c.linkStart = c.links[:last], c.linkStart[:last]
+		label := strings.TrimSpace(c.text.String()[start:])
+		if href == "" || strings.HasPrefix(href, "#") || href == label || strings.TrimPrefix(href, "mailto:") == label {
+			return
+		}
+		if label == "" {
+			c.write(href)
+		} else {
+			c.write(" (" + href + ")")
+		}
+	}
+}
+
+// attribute returns the value of the named attribute of token.
+func attribute(token html.Token, name string) string {
+	for _, attr := range token.Attr {
+		if attr.Key == name {
+			return strings.TrimSpace(attr.Val)
+		}
+	}
+	return ""
+}
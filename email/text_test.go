@@ -0,0 +1,52 @@
+package email
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TestHTMLToText runs several test cases to check the correctness of
+// the plain text versions of HTML emails.
+func TestHTMLToText(t *testing.T) {
+	tests := []struct {
+		name     string
+		html     string
+		expected string
+	}{
+		{
+			"Paragraphs and line breaks",
+			"<p>Hi <b>Richa</b>,<br/>welcome.</p><p>Bye</p>",
+			"Hi Richa,\nwelcome.\n\nBye",
+		}, {
+			"Links",
+			`<a href="http://abc.com/verify">Verify</a> or <a href="http://abc.com">http://abc.com</a> <a href="#top">top</a>`,
+			"Verify (http://abc.com/verify) or http://abc.com top",
+		}, {
+			"Image link",
+			`<a href="http://abc.com"><img src="logo.png"/></a>`,
+			"http://abc.com",
+		}, {
+			"Hidden content",
+			"<html><head><title>Mail</title><style>p{color:red}</style></head><body><script>x()</script>Text</body></html>",
+			"Text",
+		}, {
+			"Lists and tables",
+			"<ul><li>One</li><li>Two</li></ul><table><tr><td>A</td><td>B</td></tr><tr><td>C</td></tr></table>",
+			"- One\n- Two\n\nA B\nC",
+		}, {
+			"Entities and whitespace",
+			"<p>\n   Fish &amp;   chips\n</p>",
+			"Fish & chips",
+		},
+	}
+
+	for _, c := range tests {
+		t.Run(c.name, func(t *testing.T) {
+			if text := HTMLToText(c.html); text != c.expected {
+				t.Fatalf("HTMLToText %s %q", c.name, text)
+			} else {
+				fmt.Println("HTMLToText-", c.name, "Pass")
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package email
+
+import (
+	"github.com/programmer-richa/utility/constants"
+	"github.com/programmer-richa/utility/database"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TokenCollection is the default collection of the tokens issued by Tokens.
+const TokenCollection = "_tokens"
+
+// minTokenSecret is the minimum length of the secret signing tokens, the size of an HMAC-SHA256 key.
+const minTokenSecret = 32
+
+// Purposes of the tokens of the account flows. Tokens are only accepted for the purpose they were issued for.
+const (
+	PurposeVerification = "verification"
+	PurposeReset        = "reset"
+)
+
+// Errors returned by Tokens.
+var (
+	// ErrInvalidToken reports a malformed or tampered token, or a token issued for another purpose.
+	ErrInvalidToken = errors.New(constants.InvalidToken)
+	// ErrTokenExpired reports a token used after its expiry.
+	ErrTokenExpired = errors.New(constants.TokenExpired)
+	// ErrTokenUsed reports a token that was already consumed.
+	ErrTokenUsed = errors.New(constants.TokenUsed)
+)
+
+// Tokens issues signed, expiring and single-use tokens, e.g. for the links of account
+// verification and password reset emails.
+// A token carries its purpose, account and expiry, signed with HMAC-SHA256, so it cannot be forged
+// or altered. It is recorded in a collection when issued and removed when consumed,
+// so it is only accepted once.
+type Tokens struct {
+	store      database.DocumentStore
+	collection string
+	secret     []byte
+}
+
+// tokenDocument is the record of an unused token.
+type tokenDocument struct {
+	ID        primitive.ObjectID `bson:"_id"`
+	Purpose   string             `bson:"purpose"`
+	Account   string             `bson:"account"`
+	ExpiresAt time.Time          `bson:"expiresAt"`
+}
+
+// tokenClaims is the signed content of a token.
+type tokenClaims struct {
+	ID        string `json:"i"`
+	Purpose   string `json:"p"`
+	Account   string `json:"a"`
+	ExpiresAt int64  `json:"e"`
+}
+
+// NewTokens returns pointer to Tokens recording tokens in collectionName of store, or TokenCollection
+// if it is empty, and signing them with secret, which must hold at least 32 random bytes and be
+// shared by every instance.
+func NewTokens(store database.DocumentStore, collectionName string, secret []byte) (*Tokens, error) {
+	if store == nil {
+		return nil, errors.New(constants.NilMongoHelper)
+	}
+	if len(secret) < minTokenSecret {
+		return nil, errors.New(constants.ShortTokenSecret)
+	}
+	if collectionName == "" {
+		collectionName = TokenCollection
+	}
+	return &Tokens{store: store, collection: collectionName, secret: secret}, nil
+}
+
+// Indexes returns the TTL index removing expired tokens, to be passed to ReconcileIndexes.
+func (t *Tokens) Indexes() []database.IndexDefinition {
+	return []database.IndexDefinition{{Collection: t.collection, Keys: bson.D{{Key: "expiresAt", Value: 1}}, TTL: time.Second}}
+}
+
+// Issue returns a new token for account, e.g. a user id, valid for purpose during ttl.
+func (t *Tokens) Issue(ctx context.Context, purpose string, account string, ttl time.Duration) (string, error) {
+	document := tokenDocument{
+		ID:        primitive.NewObjectID(),
+		Purpose:   purpose,
+		Account:   account,
+		ExpiresAt: time.Now().Add(ttl).Truncate(time.Second),
+	}
+	payload, err := json.Marshal(tokenClaims{
+		ID:        document.ID.Hex(),
+		Purpose:   purpose,
+		Account:   account,
+		ExpiresAt: document.ExpiresAt.Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+	if _, err := t.store.InsertDocumentContext(ctx, t.collection, document); err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(t.sign(encoded)), nil
+}
+
+// Check returns the account of token if it is valid for purpose, without consuming it,
+// e.g. to display a password reset form before the token is consumed with the new password.
+func (t *Tokens) Check(ctx context.Context, token string, purpose string) (string, error) {
+	claims, err := t.verify(token, purpose)
+	if err != nil {
+		return "", err
+	}
+	found, err := t.store.IsExistingDocumentContext(ctx, t.collection, bson.M{"_id": claims.objectID()})
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return "", ErrTokenUsed
+	}
+	return claims.Account, nil
+}
+
+// Consume returns the account of token if it is valid for purpose and removes it,
+// so that it is refused afterwards. Concurrent calls with the same token succeed once.
+func (t *Tokens) Consume(ctx context.Context, token string, purpose string) (string, error) {
+	claims, err := t.verify(token, purpose)
+	if err != nil {
+		return "", err
+	}
+	if err := t.store.DeleteDocumentContext(ctx, t.collection, claims.ID, nil); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			return "", ErrTokenUsed
+		}
+		return "", err
+	}
+	return claims.Account, nil
+}
+
+// verify checks the signature, purpose and expiry of token and returns its claims.
+func (t *Tokens) verify(token string, purpose string) (tokenClaims, error) {
+	var claims tokenClaims
+	dot := strings.IndexByte(token, '.')
+	if dot < 0 {
+		return claims, ErrInvalidToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(token[dot+1:])
+	if err != nil || !hmac.Equal(signature, t.sign(token[:dot])) {
+		return claims, ErrInvalidToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(token[:dot])
+	if err != nil {
+		return claims, ErrInvalidToken
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Purpose != purpose || claims.objectID().IsZero() {
+		return claims, ErrInvalidToken
+	}
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return claims, ErrTokenExpired
+	}
+	return claims, nil
+}
+
+// sign returns the signature of the encoded payload of a token.
+func (t *Tokens) sign(payload string) []byte {
+	mac := hmac.New(sha256.New, t.secret)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+// objectID returns the id of the record of the token, zero if it is malformed.
+func (c tokenClaims) objectID() primitive.ObjectID {
+	id, _ := primitive.ObjectIDFromHex(c.ID)
+	return id
+}
@@ -0,0 +1,102 @@
+package email
+
+import (
+	"github.com/programmer-richa/utility/database"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testSecret signs the tokens of the tests.
+var testSecret = []byte("0123456789abcdef0123456789abcdef")
+
+// TestTokens runs several test cases to check that tokens are only accepted once,
+// before their expiry, for their purpose and unaltered.
+func TestTokens(t *testing.T) {
+	tokens, err := NewTokens(database.NewMemoryStore(), "", testSecret)
+	if err != nil {
+		t.Fatal("NewTokens", err)
+	}
+	ctx := context.Background()
+	issue := func(ttl time.Duration) string {
+		token, err := tokens.Issue(ctx, PurposeVerification, "user:42", ttl)
+		if err != nil {
+			t.Fatal("Issue", err)
+		}
+		return token
+	}
+	used := issue(time.Hour)
+	if _, err := tokens.Consume(ctx, used, PurposeVerification); err != nil {
+		t.Fatal("Consume", err)
+	}
+	valid := issue(time.Hour)
+	forged, _ := NewTokens(database.NewMemoryStore(), "", []byte(strings.Repeat("x", 32)))
+	forgedToken, _ := forged.Issue(ctx, PurposeVerification, "user:42", time.Hour)
+	tampered := []byte(valid)
+	tampered[5] ^= 1
+	tests := []struct {
+		name    string
+		token   string
+		purpose string
+		err     error
+	}{
+		{"Used token", used, PurposeVerification, ErrTokenUsed},
+		{"Other purpose", valid, PurposeReset, ErrInvalidToken},
+		{"Tampered token", string(tampered), PurposeVerification, ErrInvalidToken},
+		{"Forged token", forgedToken, PurposeVerification, ErrInvalidToken},
+		{"Malformed token", "abc", PurposeVerification, ErrInvalidToken},
+		{"Expired token", issue(-time.Second), PurposeVerification, ErrTokenExpired},
+		{"Valid token", valid, PurposeVerification, nil},
+	}
+
+	for _, c := range tests {
+		t.Run(c.name, func(t *testing.T) {
+			if _, err := tokens.Check(ctx, c.token, c.purpose); !errors.Is(err, c.err) {
+				t.Fatal("Check", c.name, err)
+			}
+			account, err := tokens.Consume(ctx, c.token, c.purpose)
+			if !errors.Is(err, c.err) || (err == nil && account != "user:42") {
+				t.Fatal("Consume", c.name, account, err)
+			}
+			fmt.Println("Tokens-", c.name, "Pass")
+		})
+	}
+}
+
+// TestTokensConcurrent checks that concurrent consumers of a token succeed once.
+func TestTokensConcurrent(t *testing.T) {
+	tokens, err := NewTokens(database.NewMemoryStore(), "", testSecret)
+	if err != nil {
+		t.Fatal("NewTokens", err)
+	}
+	token, err := tokens.Issue(context.Background(), PurposeReset, "user:42", time.Hour)
+	if err != nil {
+		t.Fatal("Issue", err)
+	}
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	consumed := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := tokens.Consume(context.Background(), token, PurposeReset); err == nil {
+				mu.Lock()
+				consumed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if consumed != 1 {
+		t.Fatal("Tokens concurrent", consumed)
+	}
+	if _, err := NewTokens(database.NewMemoryStore(), "", []byte("short")); err == nil {
+		t.Fatal("Tokens short secret")
+	}
+	fmt.Println("Tokens concurrent- Pass")
+}
@@ -0,0 +1,145 @@
+package email
+
+import (
+	"github.com/programmer-richa/utility/constants"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Transport delivers messages built by MailRequest.
+// from and to are the envelope sender and recipients, which may differ from the
+// message headers, e.g. for Bcc recipients.
+type Transport interface {
+	Send(from string, to []string, message []byte) error
+}
+
+// SentMessage is a message recorded by MemoryTransport.
+type SentMessage struct {
+	From    string
+	To      []string
+	Message []byte
+}
+
+// MemoryTransport records messages instead of delivering them, to check email flows in tests.
+// It is safe for concurrent use.
+type MemoryTransport struct {
+	mu       sync.Mutex
+	messages []SentMessage
+	// Err, if set, is returned by Send and no message is recorded.
+	Err error
+}
+
+// NewMemoryTransport returns pointer to MemoryTransport
+func NewMemoryTransport() *MemoryTransport {
+	return &MemoryTransport{}
+}
+
+// Send records the message.
+func (t *MemoryTransport) Send(from string, to []string, message []byte) error {
+	if len(to) == 0 {
+		return errors.New(constants.NoRecipients)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.Err != nil {
+		return t.Err
+	}
+	t.messages = append(t.messages, SentMessage{
+		From:    from,
+		To:      append([]string(nil), to...),
+		Message: append([]byte(nil), message...),
+	})
+	return nil
+}
+
+// Messages returns the recorded messages in the order they were sent.
+func (t *MemoryTransport) Messages() []SentMessage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]SentMessage(nil), t.messages...)
+}
+
+// Reset forgets the recorded messages.
+func (t *MemoryTransport) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.messages = nil
+}
+
+// FileTransport writes messages to a Maildir, to read them with a mail client during local development.
+// Every message is written to the new folder of the Maildir, preceded by Return-Path and
+// X-Envelope-To headers holding the envelope.
+type FileTransport struct {
+	Dir string
+}
+
+// maildirSequence makes the names of the messages written by the process unique.
+var maildirSequence uint64
+
+// NewFileTransport returns pointer to FileTransport, creating the Maildir folders in dir if needed.
+func NewFileTransport(dir string) (*FileTransport, error) {
+	for _, folder := range []string{"tmp", "new", "cur"} {
+		if err := os.MkdirAll(filepath.Join(dir, folder), 0700); err != nil {
+			return nil, err
+		}
+	}
+	return &FileTransport{Dir: dir}, nil
+}
+
+// Send writes the message to the Maildir.
+// The message is written to the tmp folder and then moved to the new folder, so readers never see partial messages.
+func (t *FileTransport) Send(from string, to []string, message []byte) error {
+	if len(to) == 0 {
+		return errors.New(constants.NoRecipients)
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "localhost"
+	}
+	now := time.Now()
+	name := fmt.Sprintf("%d.M%dP%dQ%d.%s", now.Unix(), now.Nanosecond()/1000, os.Getpid(),
+		atomic.AddUint64(&maildirSequence, 1), strings.Replace(hostname, "/", "_", -1))
+	content := new(bytes.Buffer)
+	fmt.Fprintf(content, "Return-Path: <%s>\r\nX-Envelope-To: %s\r\n", from, strings.Join(to, ", "))
+	content.Write(message)
+	tmp := filepath.Join(t.Dir, "tmp", name)
+	if err := ioutil.WriteFile(tmp, content.Bytes(), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(t.Dir, "new", name))
+}
+
+// LogTransport logs the envelope of messages instead of delivering them,
+// for environments where mail must not leave the process.
+type LogTransport struct {
+	// Logger defaults to the standard logger.
+	Logger *log.Logger
+	// Body logs the whole message as well.
+	Body bool
+}
+
+// Send logs the message.
+func (t *LogTransport) Send(from string, to []string, message []byte) error {
+	if len(to) == 0 {
+		return errors.New(constants.NoRecipients)
+	}
+	logf := log.Printf
+	if t.Logger != nil {
+		logf = t.Logger.Printf
+	}
+	if t.Body {
+		logf("mail from %s to %s (%d bytes):\n%s", from, strings.Join(to, ", "), len(message), message)
+	} else {
+		logf("mail from %s to %s (%d bytes)", from, strings.Join(to, ", "), len(message))
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package email
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestTransports runs several test cases to check that every Transport
+// implementation rejects messages without recipients and accepts the others.
+func TestTransports(t *testing.T) {
+	dir, err := ioutil.TempDir("", "maildir")
+	if err != nil {
+		t.Fatal("TempDir", err)
+	}
+	defer os.RemoveAll(dir)
+	fileTransport, err := NewFileTransport(dir)
+	if err != nil {
+		t.Fatal("NewFileTransport", err)
+	}
+	logs := new(bytes.Buffer)
+	memoryTransport := NewMemoryTransport()
+	transports := map[string]Transport{
+		"Memory": memoryTransport,
+		"File":   fileTransport,
+		"Log":    &LogTransport{Logger: log.New(logs, "", 0)},
+	}
+	message := []byte("Subject: Hello\r\n\r\nHi\r\n")
+
+	for name, transport := range transports {
+		t.Run(name, func(t *testing.T) {
+			if err := transport.Send("from@abc.com", nil, message); err == nil {
+				t.Fatal("Transport accepted no recipients", name)
+			}
+			if err := transport.Send("from@abc.com", []string{"a@abc.com", "b@abc.com"}, message); err != nil {
+				t.Fatal("Transport", name, err)
+			} else {
+				fmt.Println("Transport-", name, "Pass")
+			}
+		})
+	}
+
+	if sent := memoryTransport.Messages(); len(sent) != 1 || sent[0].From != "from@abc.com" ||
+		len(sent[0].To) != 2 || !bytes.Equal(sent[0].Message, message) {
+		t.Fatal("MemoryTransport", sent)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "new", "*"))
+	if len(files) != 1 {
+		t.Fatal("FileTransport", files)
+	}
+	written, _ := ioutil.ReadFile(files[0])
+	if !strings.HasPrefix(string(written), "Return-Path: <from@abc.com>\r\nX-Envelope-To: a@abc.com, b@abc.com\r\nSubject: Hello") {
+		t.Fatal("FileTransport", string(written))
+	}
+	if logs.String() != "mail from from@abc.com to a@abc.com, b@abc.com (22 bytes)\n" {
+		t.Fatal("LogTransport", logs.String())
+	}
+}
@@ -0,0 +1,178 @@
+package email
+
+import (
+	"github.com/programmer-richa/utility/constants"
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// DefaultTokenTTL is the validity of the tokens sent by a Verification unless set with VerificationOptions.
+const DefaultTokenTTL = 24 * time.Hour
+
+// defaultConfirmForm is the page shown by the handler of a Verification unless set with VerificationOptions.
+var defaultConfirmForm = template.Must(template.New("confirm").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Confirm</title></head><body>
+<form method="post" action="{{ .Action }}">
+<input type="hidden" name="token" value="{{ .Token }}">
+<button type="submit">Confirm</button>
+</form>
+</body></html>
+`))
+
+// VerificationOptions configures a Verification.
+type VerificationOptions struct {
+	// Purpose of the tokens, e.g. PurposeVerification or PurposeReset.
+	Purpose string
+	// TTL is the validity of the tokens, DefaultTokenTTL by default.
+	TTL time.Duration
+	// URL is the address of the page or Handler receiving the token in the token query parameter,
+	// e.g. https://abc.com/account/verify.
+	URL string
+	// Template is the email of the Registry sending the link, which it renders as .URL,
+	// e.g. account_verification.gohtml.
+	Template string
+	// From is the sender of the emails.
+	From string
+	// ConfirmForm renders the page shown by the handler when the link is opened, a form posting
+	// the hidden token field .Token to .Action. A page with a single confirm button is shown by default.
+	ConfirmForm *template.Template
+}
+
+// VerifiedFunc is called by the handler of a Verification with the account of a consumed token,
+// e.g. to activate the account or save the new password posted with the token, and writes the response.
+type VerifiedFunc func(w http.ResponseWriter, r *http.Request, account string)
+
+// Verification sends emails holding a link with a token, e.g. to verify the address of an account
+// or reset its password, and verifies the token when the link is followed.
+type Verification struct {
+	tokens    *Tokens
+	transport Transport
+	registry  *Registry
+	options   VerificationOptions
+	url       *url.URL
+}
+
+// NewVerification returns pointer to Verification issuing tokens with tokens and sending
+// the template of registry through transport.
+func NewVerification(tokens *Tokens, transport Transport, registry *Registry, options VerificationOptions) (*Verification, error) {
+	if tokens == nil {
+		return nil, errors.New(constants.NilTokens)
+	}
+	if transport == nil {
+		return nil, errors.New(constants.NilTransport)
+	}
+	if registry == nil {
+		return nil, errors.New(constants.NilRegistry)
+	}
+	if options.Purpose == "" {
+		return nil, errors.New(constants.NoTokenPurpose)
+	}
+	if _, ok := registry.emails[options.Template]; !ok {
+		return nil, fmt.Errorf("%s %q", constants.UnknownTemplate, options.Template)
+	}
+	link, err := url.Parse(options.URL)
+	if err != nil || !link.IsAbs() {
+		return nil, fmt.Errorf("%s %q", constants.InvalidLinkURL, options.URL)
+	}
+	if options.TTL <= 0 {
+		options.TTL = DefaultTokenTTL
+	}
+	if options.ConfirmForm == nil {
+		options.ConfirmForm = defaultConfirmForm
+	}
+	return &Verification{tokens: tokens, transport: transport, registry: registry, options: options, url: link}, nil
+}
+
+// Link returns the URL holding a new token for account.
+func (v *Verification) Link(ctx context.Context, account string) (string, error) {
+	token, err := v.tokens.Issue(ctx, v.options.Purpose, account, v.options.TTL)
+	if err != nil {
+		return "", err
+	}
+	link := *v.url
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+	return link.String(), nil
+}
+
+// Send emails a link holding a new token for account to the address to.
+// The template is rendered with data and the link as URL.
+func (v *Verification) Send(ctx context.Context, to string, account string, data map[string]interface{}) error {
+	link, err := v.Link(ctx, account)
+	if err != nil {
+		return err
+	}
+	values := map[string]interface{}{}
+	for key, value := range data {
+		values[key] = value
+	}
+	values["URL"] = link
+	rendered, err := v.registry.Render(v.options.Template, values)
+	if err != nil {
+		return err
+	}
+	message := NewMessage(v.options.From, []string{to}, rendered.Subject)
+	message.ContentType = MIME_HTML
+	message.Body = []byte(rendered.HTML)
+	message.PlainText = []byte(rendered.Text)
+	return SendMessage(v.transport, message)
+}
+
+// Handler returns a handler verifying the token of the token parameter, of the query or of a posted form.
+// Opening the link only checks the token and shows the ConfirmForm, so that mail scanners and browsers
+// prefetching the link do not use it up. The form posts the token back, and the handler then consumes it
+// and calls verified with its account. Invalid, expired and used tokens are answered with 400 Bad Request.
+// The token is consumed before verified is called, so a failure of verified requires a new link.
+// Flows asking for input, such as a new password, show their own form after checking the token
+// with Tokens.Check and post it with the token to the handler.
+func (v *Verification) Handler(verified VerifiedFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var account string
+		var err error
+		switch r.Method {
+		case http.MethodGet:
+			_, err = v.tokens.Check(r.Context(), r.FormValue("token"), v.options.Purpose)
+		case http.MethodPost:
+			account, err = v.tokens.Consume(r.Context(), r.FormValue("token"), v.options.Purpose)
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		if err != nil {
+			if errors.Is(err, ErrInvalidToken) || errors.Is(err, ErrTokenExpired) || errors.Is(err, ErrTokenUsed) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+			} else {
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			}
+			return
+		}
+		if r.Method == http.MethodGet {
+			v.confirm(w, r)
+			return
+		}
+		verified(w, r, account)
+	})
+}
+
+// confirm writes the ConfirmForm posting the token of r to the URL of r, without the token.
+func (v *Verification) confirm(w http.ResponseWriter, r *http.Request) {
+	action := *r.URL
+	query := action.Query()
+	query.Del("token")
+	action.RawQuery = query.Encode()
+	// The page holds the token, so it is neither cached nor leaked to the sites it links to.
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	data := map[string]string{"Action": action.RequestURI(), "Token": r.FormValue("token")}
+	if err := v.options.ConfirmForm.Execute(w, data); err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,152 @@
+package email
+
+import (
+	"github.com/programmer-richa/utility/constants"
+	"github.com/programmer-richa/utility/database"
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"mime/quotedprintable"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// TestVerification runs several test cases to check that the link emailed by a Verification
+// is accepted once by its handler.
+func TestVerification(t *testing.T) {
+	tokens, err := NewTokens(database.NewMemoryStore(), "", testSecret)
+	if err != nil {
+		t.Fatal("NewTokens", err)
+	}
+	registry, err := NewDefaultRegistry(RegistryOptions{})
+	if err != nil {
+		t.Fatal("NewDefaultRegistry", err)
+	}
+	transport := NewMemoryTransport()
+	verification, err := NewVerification(tokens, transport, registry, VerificationOptions{
+		Purpose:  PurposeVerification,
+		URL:      "https://abc.com/account/verify?lang=en",
+		Template: "account_verification.gohtml",
+		From:     "Richa <richa@abc.com>",
+	})
+	if err != nil {
+		t.Fatal("NewVerification", err)
+	}
+	if err := verification.Send(context.Background(), "user@abc.com", "user:42", map[string]interface{}{"Name": "Richa"}); err != nil {
+		t.Fatal("Send", err)
+	}
+	message := transport.Messages()[0]
+	decoded, _ := ioutil.ReadAll(quotedprintable.NewReader(bytes.NewReader(message.Message)))
+	link := regexp.MustCompile(`https://abc\.com/account/verify\?[^\s"<]+`).FindString(string(decoded))
+	if link == "" || !bytes.Contains(message.Message, []byte("Subject: Verify your account")) {
+		t.Fatal("Verification email", string(decoded))
+	}
+	parsed, err := url.Parse(strings.Replace(link, "&amp;", "&", -1))
+	if err != nil || parsed.Query().Get("lang") != "en" {
+		t.Fatal("Verification link", link, err)
+	}
+	token := parsed.Query().Get("token")
+	var verified []string
+	handler := verification.Handler(func(w http.ResponseWriter, r *http.Request, account string) {
+		verified = append(verified, account)
+		http.Redirect(w, r, "/welcome", http.StatusSeeOther)
+	})
+	tests := []struct {
+		name   string
+		method string
+		token  string
+		status int
+	}{
+		{"Tampered token", http.MethodGet, token + "x", http.StatusBadRequest},
+		{"Opened link", http.MethodGet, token, http.StatusOK},
+		{"Prefetched link", http.MethodGet, token, http.StatusOK},
+		{"Confirmed token", http.MethodPost, token, http.StatusSeeOther},
+		{"Used token", http.MethodGet, token, http.StatusBadRequest},
+		{"Posted used token", http.MethodPost, token, http.StatusBadRequest},
+		{"Missing token", http.MethodPost, "", http.StatusBadRequest},
+		{"Wrong method", http.MethodDelete, token, http.StatusMethodNotAllowed},
+	}
+
+	for _, c := range tests {
+		t.Run(c.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			var request *http.Request
+			if c.method == http.MethodPost {
+				form := url.Values{"token": {c.token}}
+				request = httptest.NewRequest(c.method, "/account/verify?lang=en", strings.NewReader(form.Encode()))
+				request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			} else {
+				request = httptest.NewRequest(c.method, "/account/verify?lang=en&token="+url.QueryEscape(c.token), nil)
+			}
+			handler.ServeHTTP(recorder, request)
+			if recorder.Code != c.status {
+				t.Fatal("Verification handler", c.name, recorder.Code, recorder.Body.String())
+			}
+			if c.status == http.StatusOK && (!strings.Contains(recorder.Body.String(), `action="/account/verify?lang=en"`) ||
+				!strings.Contains(recorder.Body.String(), `value="`+token+`"`)) {
+				t.Fatal("Confirm form", c.name, recorder.Body.String())
+			}
+			fmt.Println("Verification-", c.name, "Pass")
+		})
+	}
+	if len(verified) != 1 || verified[0] != "user:42" {
+		t.Fatal("Verification callback", verified)
+	}
+}
+
+// TestPasswordReset checks a reset flow posting the new password with a checked token.
+func TestPasswordReset(t *testing.T) {
+	tokens, err := NewTokens(database.NewMemoryStore(), "", testSecret)
+	if err != nil {
+		t.Fatal("NewTokens", err)
+	}
+	registry, err := NewDefaultRegistry(RegistryOptions{})
+	if err != nil {
+		t.Fatal("NewDefaultRegistry", err)
+	}
+	reset, err := NewVerification(tokens, NewMemoryTransport(), registry, VerificationOptions{
+		Purpose:  PurposeReset,
+		URL:      "https://abc.com/account/reset",
+		Template: "password_reset.gohtml",
+		From:     "richa@abc.com",
+	})
+	if err != nil {
+		t.Fatal("NewVerification", err)
+	}
+	link, err := reset.Link(context.Background(), "user:42")
+	if err != nil {
+		t.Fatal("Link", err)
+	}
+	parsed, _ := url.Parse(link)
+	token := parsed.Query().Get("token")
+	if account, err := tokens.Check(context.Background(), token, PurposeReset); err != nil || account != "user:42" {
+		t.Fatal("Check", account, err)
+	}
+	var password string
+	handler := reset.Handler(func(w http.ResponseWriter, r *http.Request, account string) {
+		password = r.FormValue("password")
+	})
+	form := url.Values{"token": {token}, "password": {"n3w-passw0rd"}}
+	request := httptest.NewRequest(http.MethodPost, "/account/reset", strings.NewReader(form.Encode()))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusOK || password != "n3w-passw0rd" {
+		t.Fatal("Password reset", recorder.Code, password)
+	}
+	if _, err := NewVerification(tokens, NewMemoryTransport(), registry,
+		VerificationOptions{Purpose: PurposeReset, Template: "password_reset.gohtml", URL: "/reset"}); err == nil {
+		t.Fatal("Relative link")
+	}
+	if _, err := NewVerification(tokens, NewMemoryTransport(), registry,
+		VerificationOptions{Template: "password_reset.gohtml", URL: "https://abc.com/account/reset"}); err == nil ||
+		err.Error() != constants.NoTokenPurpose {
+		t.Fatal("Missing purpose", err)
+	}
+	fmt.Println("Password reset- Pass")
+}
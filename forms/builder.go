@@ -12,6 +12,8 @@ type FieldBuilder struct {
 	max        int
 	fieldType  string
 	placeholder string
+	accept     []string
+	maxSize    int64
 }
 
 // WithFormatters specifies the name of the formatting function of the field.
@@ -76,6 +78,18 @@ func (fb *FieldBuilder) Empty(value interface{}) *FieldBuilder {
 	return fb
 }
 
+// Accept sets the content types accepted by a FILE field, e.g. "image/png" or "image/*".
+func (fb *FieldBuilder) Accept(contentTypes ...string) *FieldBuilder {
+	fb.accept = append(fb.accept, contentTypes...)
+	return fb
+}
+
+// MaxSize sets the size limit in bytes of the file uploaded in a FILE field.
+func (fb *FieldBuilder) MaxSize(bytes int64) *FieldBuilder {
+	fb.maxSize = bytes
+	return fb
+}
+
 // Build returns a pointer to Field
 func (fb *FieldBuilder) Build() *Field {
 	return &Field{
@@ -89,5 +103,7 @@ func (fb *FieldBuilder) Build() *Field {
 		Label:      fb.label,
 		FieldType:  fb.fieldType,
 		Placeholder: fb.placeholder,
+		Accept:     fb.accept,
+		MaxSize:    fb.maxSize,
 	}
 }
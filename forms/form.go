@@ -3,7 +3,9 @@
 package forms
 
 import (
+	"net/http"
 	"net/url"
+	"strings"
 )
 
 // The below constant block contains the list of form field types.
@@ -15,8 +17,19 @@ const (
 	EMAIL    = "email"
 	CHECKBOX = "checkbox"
 	BUTTON   = "button"
+	FILE     = "file"
 )
 
+// MULTIPART is the encryption type of forms with FILE fields.
+const MULTIPART = "multipart/form-data"
+
+// defaultMaxMemory is the size of the uploaded files ValidRequest keeps in memory, larger files are stored on disk.
+const defaultMaxMemory = 32 << 20
+
+// DefaultMaxBodySize is the size limit in bytes of the request bodies parsed by ValidRequest
+// unless set in Form.MaxBodySize.
+const DefaultMaxBodySize = 64 << 20
+
 // FormValues represents a map of form field values
 type FormValues map[string]interface{}
 
@@ -35,6 +48,9 @@ type Form struct {
 	Action          string
 	Method          string
 	Name       string
+	// MaxBodySize is the size limit in bytes of the request body parsed by ValidRequest,
+	// DefaultMaxBodySize if it is 0.
+	MaxBodySize int64
 }
 
 // New returns a pointer to Form
@@ -69,7 +85,52 @@ func (f *Form) WithValidator(validator FormValidator) *Form {
 }
 
 // Valid validates every field followed by the form's validator if provided.
+// FILE fields are validated as if no file was uploaded, use ValidRequest for forms with FILE fields.
 func (f *Form) Valid(postForm url.Values) bool {
+	return f.valid(func(fname string, field *Field) (interface{}, error) {
+		if field.FieldType == FILE {
+			return field.ValidateFile(nil)
+		}
+		return field.Validate(postForm.Get(fname))
+	})
+}
+
+// ValidRequest parses the posted form of r, including uploaded files of multipart forms,
+// and validates it like Valid. The value of a FILE field is an *UploadedFile.
+// Bodies larger than MaxBodySize are rejected.
+func (f *Form) ValidRequest(r *http.Request) bool {
+	maxBodySize := f.MaxBodySize
+	if maxBodySize <= 0 {
+		maxBodySize = DefaultMaxBodySize
+	}
+	if r.Body != nil {
+		r.Body = http.MaxBytesReader(nil, r.Body, maxBodySize)
+	}
+	var err error
+	if strings.HasPrefix(r.Header.Get("Content-Type"), MULTIPART) {
+		err = r.ParseMultipartForm(defaultMaxMemory)
+	} else {
+		err = r.ParseForm()
+	}
+	if err != nil {
+		f.Values = nil
+		f.Errors = map[string]error{"": err}
+		return false
+	}
+	return f.valid(func(fname string, field *Field) (interface{}, error) {
+		if field.FieldType != FILE {
+			return field.Validate(r.PostForm.Get(fname))
+		}
+		if r.MultipartForm == nil || len(r.MultipartForm.File[fname]) == 0 {
+			return field.ValidateFile(nil)
+		}
+		return field.ValidateFile(r.MultipartForm.File[fname][0])
+	})
+}
+
+// valid validates every field, using validate to validate the posted value of a field,
+// followed by the form's validator if provided.
+func (f *Form) valid(validate func(fname string, field *Field) (interface{}, error)) bool {
 	valid := true
 
 	f.Errors = nil
@@ -80,7 +141,7 @@ func (f *Form) Valid(postForm url.Values) bool {
 
 	// validate fields
 	for _, fname := range f.FieldNames {
-		fieldValue, fieldError := f.Fields[fname].Validate(postForm.Get(fname))
+		fieldValue, fieldError := validate(fname, f.Fields[fname])
 		if fieldError != nil {
 			valid = false
 			formErrors[fname] = fieldError
@@ -3,6 +3,8 @@ package forms
 import (
 	"github.com/programmer-richa/utility/constants"
 	"github.com/programmer-richa/utility/validators"
+	"context"
+	"net"
 	"net/http"
 	"net/url"
 	"testing"
@@ -102,3 +104,40 @@ func TestInvalidForm(t *testing.T) {
 		}
 	}
 }
+
+// failingResolver fails every DNS lookup, e.g. on timeout.
+type failingResolver struct{}
+
+func (failingResolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	return nil, &net.DNSError{Err: "i/o timeout", Name: name, IsTimeout: true}
+}
+
+func (failingResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	return nil, &net.DNSError{Err: "i/o timeout", Name: host, IsTimeout: true}
+}
+
+func TestAddressValidator(t *testing.T) {
+	disposable := validators.NewEmailValidator()
+	disposable.RejectDomains("mailinator.com")
+	unresolved := validators.NewEmailValidator()
+	unresolved.SetResolver(failingResolver{})
+	tests := []struct {
+		name      string
+		validator *validators.EmailValidator
+		address   string
+		valid     bool
+	}{
+		{"Syntax only", nil, "programmer.richa@gmail.com", true},
+		{"Syntax only invalid", nil, "programmer.richa@gmailcom", false},
+		{"Rejected domain", disposable, "richa@mailinator.com", false},
+		{"Accepted domain", disposable, "programmer.richa@gmail.com", true},
+		{"Display name", nil, "Richa <programmer.richa@gmail.com>", false},
+		{"Angle brackets", disposable, "<programmer.richa@gmail.com>", false},
+		{"Failed lookup", unresolved, "programmer.richa@gmail.com", false},
+	}
+	for _, c := range tests {
+		if err := AddressValidator(c.validator)(c.address); (err == nil) != c.valid {
+			t.Error("AddressValidator", c.name, err)
+		}
+	}
+}
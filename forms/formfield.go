@@ -22,6 +22,11 @@ type Field struct {
 	Max        int
 	Label      string
 	FieldType  string
+	// Accept lists the content types accepted by a FILE field, e.g. "image/png" or "image/*".
+	// Every type is accepted if it is empty.
+	Accept []string
+	// MaxSize is the size limit in bytes of the file uploaded in a FILE field. There is no limit if it is 0.
+	MaxSize int64
 }
 
 // Validate tests if the data passed in the field follows the specified validation rules.
@@ -0,0 +1,87 @@
+package forms
+
+import (
+	"github.com/programmer-richa/utility/constants"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+)
+
+// sniffLength is the number of bytes http.DetectContentType considers.
+const sniffLength = 512
+
+// UploadedFile is the value of a FILE field.
+// Its Header can be passed to database.FileStore.UploadFileHeader to store the file.
+type UploadedFile struct {
+	Filename string
+	// ContentType is detected from the content of the file rather than declared by the client.
+	ContentType string
+	Size        int64
+	Header      *multipart.FileHeader
+}
+
+// Open opens the uploaded file.
+func (u *UploadedFile) Open() (multipart.File, error) {
+	return u.Header.Open()
+}
+
+// ValidateFile tests if the file uploaded in a FILE field, nil if there is none,
+// follows the size, content type and specified validation rules.
+// The validators receive an *UploadedFile.
+func (f *Field) ValidateFile(header *multipart.FileHeader) (interface{}, error) {
+	if header == nil || (header.Filename == "" && header.Size == 0) {
+		if f.Required {
+			return nil, errors.New(fmt.Sprintf("%s is required", f.Name))
+		}
+		return f.Empty, nil
+	}
+	if f.MaxSize > 0 && header.Size > f.MaxSize {
+		return nil, errors.New(constants.FileTooLarge)
+	}
+	contentType, err := detectContentType(header)
+	if err != nil {
+		return nil, err
+	}
+	if !f.accepts(contentType) {
+		return nil, errors.New(constants.InvalidFileType)
+	}
+	value := &UploadedFile{Filename: header.Filename, ContentType: contentType, Size: header.Size, Header: header}
+	if err := f.validate(value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// accepts tests if contentType matches one of the accepted content types.
+func (f *Field) accepts(contentType string) bool {
+	if len(f.Accept) == 0 {
+		return true
+	}
+	for _, accepted := range f.Accept {
+		if accepted == contentType ||
+			(strings.HasSuffix(accepted, "/*") && strings.HasPrefix(contentType, strings.TrimSuffix(accepted, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// detectContentType returns the media type of the uploaded file, without parameters, sniffed from its content.
+func detectContentType(header *multipart.FileHeader) (string, error) {
+	file, err := header.Open()
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	buffer := make([]byte, sniffLength)
+	n, err := io.ReadFull(file, buffer)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	mediaType, _, err := mime.ParseMediaType(http.DetectContentType(buffer[:n]))
+	return mediaType, err
+}
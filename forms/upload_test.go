@@ -0,0 +1,105 @@
+package forms
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// pngHeader is the signature of PNG images, enough for content type detection.
+var pngHeader = []byte("\x89PNG\r\n\x1a\n")
+
+func uploadForm() *Form {
+	form := New("profile", "profile", MULTIPART, "/profile", http.MethodPost)
+
+	// name
+	form.WithField(form.PrefixFieldName+"Name", new(FieldBuilder).
+		Required().
+		Label("Name").
+		FieldType(TEXT).
+		Loader(StringLoader))
+
+	// avatar
+	form.WithField(form.PrefixFieldName+"Avatar", new(FieldBuilder).
+		Required().
+		Label("Avatar").
+		FieldType(FILE).
+		Accept("image/*").
+		MaxSize(1024))
+
+	return form
+}
+
+// uploadRequest returns a multipart request posting name and, if content is not nil, an avatar file.
+func uploadRequest(t *testing.T, name string, content []byte) *http.Request {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	writer.WriteField("profileName", name)
+	if content != nil {
+		part, err := writer.CreateFormFile("profileAvatar", "avatar.png")
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write(content)
+	}
+	writer.Close()
+	r := httptest.NewRequest(http.MethodPost, "/profile", body)
+	r.Header.Set("Content-Type", writer.FormDataContentType())
+	return r
+}
+
+func TestFileField(t *testing.T) {
+	tests := []struct {
+		name    string
+		content []byte
+		valid   bool
+	}{
+		{"Valid image", append(pngHeader, make([]byte, 100)...), true},
+		{"Missing file", nil, false},
+		{"Too large", append(pngHeader, make([]byte, 2048)...), false},
+		{"Wrong type", []byte("plain text is not an image"), false},
+	}
+
+	for _, c := range tests {
+		t.Run(c.name, func(t *testing.T) {
+			form := uploadForm()
+			valid := form.ValidRequest(uploadRequest(t, "Richa Chawla", c.content))
+			if valid != c.valid {
+				t.Fatal("ValidRequest", c.name, form.Errors)
+			}
+			if !valid {
+				if _, ok := form.Errors["profileAvatar"]; !ok {
+					t.Fatal("Form should have an error for field profileAvatar", c.name, form.Errors)
+				}
+				return
+			}
+			upload, ok := form.Values["profileAvatar"].(*UploadedFile)
+			if !ok || upload.ContentType != "image/png" || upload.Filename != "avatar.png" ||
+				upload.Size != int64(len(c.content)) || form.Values["profileName"] != "Richa Chawla" {
+				t.Fatal("ValidRequest values", c.name, form.Values)
+			}
+			file, err := upload.Open()
+			if err != nil {
+				t.Fatal("UploadedFile Open", err)
+			}
+			file.Close()
+		})
+	}
+}
+
+func TestMaxBodySize(t *testing.T) {
+	form := uploadForm()
+	form.MaxBodySize = 512
+	if form.ValidRequest(uploadRequest(t, "Richa Chawla", append(pngHeader, make([]byte, 1024)...))) {
+		t.Fatal("ValidRequest accepted a body larger than MaxBodySize")
+	}
+	if form.Errors[""] == nil {
+		t.Fatal("ValidRequest did not report the body size", form.Errors)
+	}
+	form.MaxBodySize = 0
+	if !form.ValidRequest(uploadRequest(t, "Richa Chawla", append(pngHeader, make([]byte, 100)...))) {
+		t.Fatal("ValidRequest", form.Errors)
+	}
+}
@@ -3,11 +3,16 @@ package forms
 import (
 	"github.com/programmer-richa/utility/constants"
 	"github.com/programmer-richa/utility/validators"
+	"context"
 	"errors"
 	"regexp"
 	"strings"
+	"time"
 )
 
+// AddressCheckTimeout bounds the DNS lookups of the validators returned by AddressValidator.
+const AddressCheckTimeout = 5 * time.Second
+
 // ValidatorFunc specifies the criteria of testing the field data.
 type ValidatorFunc func(interface{}) error
 
@@ -44,6 +49,43 @@ func ReValidator(pattern string, errorMsg string) ValidatorFunc {
 	})
 }
 
+// AddressValidator validates data as a single email address checked by validator, failing with the
+// first reason the address is rejected. Only the syntax is checked if validator is nil.
+// Addresses with a display name, e.g. "Richa <programmer.richa@gmail.com>", are rejected.
+// A DNS lookup that failed or did not complete within AddressCheckTimeout is returned as the error,
+// so the address is not accepted unless its mail server is known.
+func AddressValidator(validator *validators.EmailValidator) ValidatorFunc {
+	return ValidatorFunc(func(value interface{}) error {
+		strValue, ok := value.(string)
+		// check type assertion
+		if !ok {
+			return errors.New(constants.InvalidString)
+		}
+		// check single address syntax
+		address, err := validators.ParseEmail(strValue)
+		if err != nil {
+			return err
+		}
+		if address.Name != "" || strings.HasSuffix(strings.TrimSpace(strValue), ">") {
+			return validators.ErrEmailSyntax
+		}
+		if validator == nil {
+			return nil
+		}
+		// check email address
+		ctx, cancel := context.WithTimeout(context.Background(), AddressCheckTimeout)
+		defer cancel()
+		check, err := validator.Check(ctx, strValue)
+		if err != nil {
+			return err
+		}
+		if !check.Valid() {
+			return check.Reasons[0]
+		}
+		return nil
+	})
+}
+
 // RangeValidator validates data using given range of values
 func RangeValidator(min, max int, errorMsg string) ValidatorFunc {
 	return ValidatorFunc(func(value interface{}) error {
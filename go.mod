@@ -1,9 +1,10 @@
 module github.com/programmer-richa/utility
 
-go 1.14
+go 1.16
 
 require (
 	github.com/google/uuid v1.1.1
 	github.com/pkg/errors v0.8.1
 	go.mongodb.org/mongo-driver v1.3.4
+	golang.org/x/net v0.0.0-20200602114024-627f9648deb9
 )
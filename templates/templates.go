@@ -0,0 +1,13 @@
+// Package templates holds the email templates: the standalone templates of MailRequest under
+// templates/emails, parsed with template.ParseGlob, and the templates of email.NewDefaultRegistry,
+// embedded from templates/mail.
+package templates
+
+import "embed"
+
+// Mail holds the email templates of email.NewDefaultRegistry under the mail folder: layouts in mail/layouts,
+// partials in mail/partials and the emails themselves with their plain text companions,
+// e.g. account_verification.gohtml and password_reset.gohtml.
+//
+//go:embed mail
+var Mail embed.FS
@@ -0,0 +1,211 @@
+package validators
+
+import (
+	"github.com/programmer-richa/utility/constants"
+	"bufio"
+	"context"
+	_ "embed"
+	"errors"
+	"io"
+	"net"
+	"net/mail"
+	"strings"
+	"golang.org/x/net/idna"
+)
+
+// Limits of the lengths of email addresses set by RFC 5321.
+const (
+	maxLocalLength   = 64
+	maxAddressLength = 254
+)
+
+// Reasons an email address is rejected, returned by ParseEmail and EmailValidator.Check.
+var (
+	// ErrEmailSyntax reports an address that is not a valid RFC 5322 address.
+	ErrEmailSyntax = errors.New(constants.InvalidEmail)
+	// ErrEmailDomain reports a domain that is not a valid host name.
+	ErrEmailDomain = errors.New(constants.InvalidEmailDomain)
+	// ErrDisposableEmail reports a domain of a disposable email service.
+	ErrDisposableEmail = errors.New(constants.DisposableEmail)
+	// ErrNoMailServer reports a domain that does not accept mail.
+	ErrNoMailServer = errors.New(constants.NoMailServer)
+)
+
+// disposableDomains is the list of disposable email domains returned by DisposableDomains.
+//
+//go:embed disposable_domains.txt
+var disposableDomains string
+
+// EmailAddress is a parsed email address.
+type EmailAddress struct {
+	// Name is the display name, e.g. Richa for "Richa <programmer.richa@gmail.com>".
+	Name  string
+	Local string
+	// Domain is the lower case ASCII form of the domain, internationalised domains being
+	// converted to punycode, e.g. xn--bcher-kva.de for bücher.de.
+	Domain string
+	// Address is the normalised address, Local@Domain.
+	Address string
+}
+
+// ParseEmail parses s as an RFC 5322 address, which may hold a display name,
+// and normalises its domain. It returns ErrEmailSyntax or ErrEmailDomain if s is invalid.
+func ParseEmail(s string) (EmailAddress, error) {
+	parsed, err := mail.ParseAddress(strings.TrimSpace(s))
+	if err != nil {
+		return EmailAddress{}, ErrEmailSyntax
+	}
+	at := strings.LastIndex(parsed.Address, "@")
+	local, domain := parsed.Address[:at], parsed.Address[at+1:]
+	if len(local) > maxLocalLength {
+		return EmailAddress{}, ErrEmailSyntax
+	}
+	domain, err = idna.Lookup.ToASCII(domain)
+	if err != nil || !strings.Contains(domain, ".") {
+		return EmailAddress{}, ErrEmailDomain
+	}
+	address := EmailAddress{Name: parsed.Name, Local: local, Domain: domain, Address: local + "@" + domain}
+	if len(address.Address) > maxAddressLength {
+		return EmailAddress{}, ErrEmailSyntax
+	}
+	return address, nil
+}
+
+// MXResolver looks up the DNS records telling if a domain accepts mail.
+// net.DefaultResolver implements it; tests use a fake DNS.
+type MXResolver interface {
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// EmailCheck is the outcome of EmailValidator.Check.
+type EmailCheck struct {
+	EmailAddress
+	// Reasons lists why the address is rejected, e.g. ErrDisposableEmail, empty if it is accepted.
+	Reasons []error
+}
+
+// Valid tests if the address is accepted.
+func (c EmailCheck) Valid() bool {
+	return len(c.Reasons) == 0
+}
+
+// EmailValidator checks email addresses beyond their syntax: it optionally rejects disposable
+// domains and domains that do not accept mail.
+type EmailValidator struct {
+	domains  map[string]bool
+	resolver MXResolver
+}
+
+// NewEmailValidator returns pointer to EmailValidator checking the syntax of addresses only.
+func NewEmailValidator() *EmailValidator {
+	return &EmailValidator{domains: map[string]bool{}}
+}
+
+// DisposableDomains returns a list of common disposable email domains, to be passed to RejectDomains.
+func DisposableDomains() []string {
+	domains, _ := readDomains(strings.NewReader(disposableDomains))
+	return domains
+}
+
+// RejectDomains makes the validator reject the addresses of domains and of their subdomains.
+// Internationalised domains may be given in Unicode or punycode.
+func (v *EmailValidator) RejectDomains(domains ...string) {
+	for _, domain := range domains {
+		if ascii, err := idna.Lookup.ToASCII(strings.TrimSpace(domain)); err == nil && ascii != "" {
+			v.domains[ascii] = true
+		}
+	}
+}
+
+// LoadRejectedDomains reads the domains to reject from r, one per line, e.g. a published list
+// of disposable domains. Blank lines and lines starting with # are ignored.
+func (v *EmailValidator) LoadRejectedDomains(r io.Reader) error {
+	domains, err := readDomains(r)
+	if err != nil {
+		return err
+	}
+	v.RejectDomains(domains...)
+	return nil
+}
+
+// SetResolver makes the validator reject domains without mail server, looked up with resolver,
+// e.g. net.DefaultResolver.
+func (v *EmailValidator) SetResolver(resolver MXResolver) {
+	v.resolver = resolver
+}
+
+// Check parses s and returns the reasons it is rejected, if any.
+// The error reports a DNS lookup that failed, e.g. on timeout, in which case the mail server
+// of the domain is unknown and not listed in the reasons.
+func (v *EmailValidator) Check(ctx context.Context, s string) (EmailCheck, error) {
+	address, err := ParseEmail(s)
+	if err != nil {
+		return EmailCheck{Reasons: []error{err}}, nil
+	}
+	check := EmailCheck{EmailAddress: address}
+	if v.rejected(address.Domain) {
+		check.Reasons = append(check.Reasons, ErrDisposableEmail)
+	}
+	if v.resolver == nil {
+		return check, nil
+	}
+	accepts, err := v.acceptsMail(ctx, address.Domain)
+	if err != nil {
+		return check, err
+	}
+	if !accepts {
+		check.Reasons = append(check.Reasons, ErrNoMailServer)
+	}
+	return check, nil
+}
+
+// rejected tests if domain or one of its parent domains is rejected.
+func (v *EmailValidator) rejected(domain string) bool {
+	for {
+		if v.domains[domain] {
+			return true
+		}
+		dot := strings.IndexByte(domain, '.')
+		if dot < 0 {
+			return false
+		}
+		domain = domain[dot+1:]
+	}
+}
+
+// acceptsMail tests if domain has a mail server: an MX record other than the null MX of RFC 7505,
+// or else an address record, which RFC 5321 treats as an implicit MX.
+func (v *EmailValidator) acceptsMail(ctx context.Context, domain string) (bool, error) {
+	records, err := v.resolver.LookupMX(ctx, domain)
+	if err != nil && !isNotFound(err) {
+		return false, err
+	}
+	if len(records) > 0 {
+		return !(len(records) == 1 && (records[0].Host == "." || records[0].Host == "")), nil
+	}
+	hosts, err := v.resolver.LookupHost(ctx, domain)
+	if err != nil && !isNotFound(err) {
+		return false, err
+	}
+	return len(hosts) > 0, nil
+}
+
+// isNotFound tests if err reports a domain or record that does not exist.
+func isNotFound(err error) bool {
+	var dnsError *net.DNSError
+	return errors.As(err, &dnsError) && dnsError.IsNotFound
+}
+
+// readDomains returns the lower case domains listed in r, one per line.
+func readDomains(r io.Reader) ([]string, error) {
+	var domains []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if line != "" && !strings.HasPrefix(line, "#") {
+			domains = append(domains, line)
+		}
+	}
+	return domains, scanner.Err()
+}
@@ -0,0 +1,120 @@
+package validators
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+)
+
+// fakeResolver answers DNS lookups from its records, reporting other domains as not found.
+type fakeResolver struct {
+	mx    map[string][]*net.MX
+	hosts map[string][]string
+	// failing domains fail with a temporary error.
+	failing map[string]bool
+}
+
+// LookupMX returns the MX records of name.
+func (r *fakeResolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	if r.failing[name] {
+		return nil, &net.DNSError{Err: "timeout", Name: name, IsTimeout: true}
+	}
+	if records, ok := r.mx[name]; ok {
+		return records, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+}
+
+// LookupHost returns the addresses of host.
+func (r *fakeResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	if hosts, ok := r.hosts[host]; ok {
+		return hosts, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+}
+
+// TestParseEmail runs several test cases to check the parsing and normalisation of email addresses.
+func TestParseEmail(t *testing.T) {
+	tests := []struct {
+		name    string
+		email   string
+		address EmailAddress
+		err     error
+	}{
+		{"Plain address", "abc@gmail.com", EmailAddress{Local: "abc", Domain: "gmail.com", Address: "abc@gmail.com"}, nil},
+		{"Display name", "Richa <Programmer.Richa@GMail.com>", EmailAddress{Name: "Richa", Local: "Programmer.Richa", Domain: "gmail.com", Address: "Programmer.Richa@gmail.com"}, nil},
+		{"Quoted display name", `"Richa, Programmer" <richa@abc.com>`, EmailAddress{Name: "Richa, Programmer", Local: "richa", Domain: "abc.com", Address: "richa@abc.com"}, nil},
+		{"Internationalised domain", "info@Bücher.de", EmailAddress{Local: "info", Domain: "xn--bcher-kva.de", Address: "info@xn--bcher-kva.de"}, nil},
+		{"Empty", "", EmailAddress{}, ErrEmailSyntax},
+		{"Without @ symbol", "abcdgmail.yahoo", EmailAddress{}, ErrEmailSyntax},
+		{"Ending with .", "abc@gmail.com.", EmailAddress{}, ErrEmailSyntax},
+		{"Too long local part", strings.Repeat("a", 65) + "@gmail.com", EmailAddress{}, ErrEmailSyntax},
+		{"Without . in domain", "abcd@gmailyahoo", EmailAddress{}, ErrEmailDomain},
+		{"Invalid host name", "abc@gmail_yahoo.com", EmailAddress{}, ErrEmailDomain},
+	}
+
+	for _, c := range tests {
+		t.Run(c.name, func(t *testing.T) {
+			address, err := ParseEmail(c.email)
+			if err != c.err || address != c.address {
+				t.Fatal("ParseEmail Failed", c.name, address, err)
+			} else {
+				fmt.Println("ParseEmail-", c.name, "Pass")
+			}
+		})
+	}
+}
+
+// TestEmailValidator runs several test cases to check the reasons email addresses are rejected
+// for, looking up domains in a fake DNS.
+func TestEmailValidator(t *testing.T) {
+	validator := NewEmailValidator()
+	validator.RejectDomains(DisposableDomains()...)
+	if err := validator.LoadRejectedDomains(strings.NewReader("# spam\n\nSpam.example\n")); err != nil {
+		t.Fatal("LoadRejectedDomains", err)
+	}
+	validator.SetResolver(&fakeResolver{
+		mx: map[string][]*net.MX{
+			"gmail.com":        {{Host: "gmail-smtp-in.l.google.com.", Pref: 5}},
+			"xn--bcher-kva.de": {{Host: "mx.xn--bcher-kva.de.", Pref: 10}},
+			"mailinator.com":   {{Host: "mail.mailinator.com.", Pref: 10}},
+			"nomail.com":       {{Host: ".", Pref: 0}},
+		},
+		hosts:   map[string][]string{"implicit.com": {"192.0.2.1"}},
+		failing: map[string]bool{"slow.com": true},
+	})
+	tests := []struct {
+		name    string
+		email   string
+		reasons []error
+		err     bool
+	}{
+		{"Valid address", "Richa <richa@gmail.com>", nil, false},
+		{"Internationalised domain", "info@bücher.de", nil, false},
+		{"Implicit MX", "abc@implicit.com", nil, false},
+		{"Invalid syntax", "abc", []error{ErrEmailSyntax}, false},
+		{"Disposable domain", "abc@mailinator.com", []error{ErrDisposableEmail}, false},
+		{"Disposable subdomain without mail server", "abc@x.spam.example", []error{ErrDisposableEmail, ErrNoMailServer}, false},
+		{"Null MX", "abc@nomail.com", []error{ErrNoMailServer}, false},
+		{"Unknown domain", "abc@unknown.com", []error{ErrNoMailServer}, false},
+		{"Failed lookup", "abc@slow.com", nil, true},
+	}
+
+	for _, c := range tests {
+		t.Run(c.name, func(t *testing.T) {
+			check, err := validator.Check(context.Background(), c.email)
+			if (err != nil) != c.err || len(check.Reasons) != len(c.reasons) || check.Valid() != (len(c.reasons) == 0) {
+				t.Fatal("EmailValidator Failed", c.name, check.Reasons, err)
+			}
+			for i, reason := range c.reasons {
+				if !errors.Is(check.Reasons[i], reason) {
+					t.Fatal("EmailValidator Failed", c.name, check.Reasons)
+				}
+			}
+			fmt.Println("EmailValidator-", c.name, "Pass")
+		})
+	}
+}